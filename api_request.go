@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -18,21 +18,27 @@ var (
 	errInvalidParamText         = "invalid %s parameter"
 )
 
+// HasKeyInReqParams reports whether the route matched for r has the URL parameter
+// key, as seen by Params.
 func HasKeyInReqParams(r *http.Request, key string) bool {
-	return InArray[string](
-		[]string{key},
-		chi.NewRouteContext().URLParams.Keys,
-		false,
-	)
+	_, ok := Params.Param(r, key)
+	return ok
 }
+
+// ReadParam reads the URL parameter key through Params. It fails with
+// ErrNotExistsInRequestParams when the route has no such parameter and with an
+// invalid parameter error when it is empty.
 func ReadParam(r *http.Request, key string) (string, bool, error) {
-	var value string
-	keyExists := !HasKeyInReqParams(r, key)
+	return ReadParamFrom(Params, r, key)
+}
+
+// ReadParamFrom is ReadParam with an explicit ParamReader.
+func ReadParamFrom(p ParamReader, r *http.Request, key string) (string, bool, error) {
+	value, keyExists := p.Param(r, key)
 	if !keyExists {
 		return value, false, ErrNotExistsInRequestParams
 	}
 
-	value = chi.URLParam(r, key)
 	if value == "" {
 		return value, keyExists, fmt.Errorf(errInvalidParamText, key)
 	}
@@ -70,52 +76,213 @@ func ReadNullUUIDParam(r *http.Request, key string) (uuid.NullUUID, bool, error)
 	return uid, keyExists, nil
 }
 
+// JSONReadOptions configures ReadJSONWithOptions.
+type JSONReadOptions struct {
+	// MaxBytes limits the size of the request body. Defaults to 1MB.
+	MaxBytes int64
+	// AllowUnknownFields ignores keys that have no matching field in dst instead of
+	// failing.
+	AllowUnknownFields bool
+	// AllowMultipleValues decodes the first JSON value and ignores anything after it
+	// instead of failing.
+	AllowMultipleValues bool
+}
+
+// JSONErrorKind classifies a JSONError.
+type JSONErrorKind int
+
+const (
+	JSONSyntax JSONErrorKind = iota + 1
+	JSONUnknownField
+	JSONTypeMismatch
+	JSONTooLarge
+	JSONEmpty
+	JSONMultipleValues
+)
+
+func (k JSONErrorKind) String() string {
+	switch k {
+	case JSONSyntax:
+		return "syntax"
+	case JSONUnknownField:
+		return "unknown_field"
+	case JSONTypeMismatch:
+		return "type_mismatch"
+	case JSONTooLarge:
+		return "too_large"
+	case JSONEmpty:
+		return "empty"
+	case JSONMultipleValues:
+		return "multiple_values"
+	}
+
+	return "unknown"
+}
+
+// JSONError is returned by ReadJSON when the body cannot be decoded into dst. Its
+// message is safe to show to clients; use errors.As to branch on Kind:
+//
+//	var jsonErr *helpers.JSONError
+//	if errors.As(err, &jsonErr) && jsonErr.Kind == helpers.JSONTooLarge { ... }
+type JSONError struct {
+	Kind JSONErrorKind
+	// Field is the offending field for JSONUnknownField and, when known,
+	// JSONTypeMismatch.
+	Field string
+	// Offset is the byte offset of the problem for JSONSyntax and JSONTypeMismatch,
+	// or -1 when the body ended early.
+	Offset int64
+	// Limit is the body size limit for JSONTooLarge.
+	Limit int64
+	// Err is the underlying decoder error.
+	Err error
+}
+
+func (e *JSONError) Error() string {
+	switch e.Kind {
+	case JSONSyntax:
+		if e.Offset < 0 {
+			return "body contains badly-formed JSON"
+		}
+		return fmt.Sprintf("body contains badly-formed JSON (at character %d)", e.Offset)
+	case JSONTypeMismatch:
+		if e.Field != "" {
+			return fmt.Sprintf("body contains incorrect JSON type for field %q", e.Field)
+		}
+		return fmt.Sprintf("body contains incorrect JSON type (at character %d)", e.Offset)
+	case JSONEmpty:
+		return "body must not be empty"
+	case JSONUnknownField:
+		return fmt.Sprintf("body contains unknown key %q", e.Field)
+	case JSONTooLarge:
+		return fmt.Sprintf("body must not be larger than %d bytes", e.Limit)
+	case JSONMultipleValues:
+		return "body must only contain a single JSON value"
+	}
+
+	return "body contains invalid JSON"
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+func (o JSONReadOptions) maxBytes() int64 {
+	if o.MaxBytes <= 0 {
+		return 1_048_576
+	}
+
+	return o.MaxBytes
+}
+
 func ReadJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
-	// Use http.MaxBytesReader() to limit the size of the request body to 1MB.
-	maxBytes := 1_048_576
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+	return ReadJSONWithOptions(w, r, dst, JSONReadOptions{})
+}
+
+// ReadJSONWithOptions is ReadJSON with a configurable body limit and strictness.
+func ReadJSONWithOptions(w http.ResponseWriter, r *http.Request, dst interface{}, opts JSONReadOptions) error {
+	// Use http.MaxBytesReader() to limit the size of the request body, 1MB by default.
+	maxBytes := opts.maxBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
 	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
+	if !opts.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
 
 	err := dec.Decode(dst)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
-
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
-		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
-		case errors.As(err, &invalidUnmarshalError):
-			panic(err)
-		default:
-			return err
-		}
+		return decodeError(err, maxBytes)
+	}
+
+	if opts.AllowMultipleValues {
+		return nil
 	}
 
 	err = dec.Decode(&struct{}{})
 	if err != io.EOF {
-		return errors.New("body must only contain a single JSON value")
+		return &JSONError{Kind: JSONMultipleValues, Err: err}
 	}
 
 	return nil
 }
 
+// ReadJSONArray decodes a JSON array body one element at a time, calling fn for
+// each, so bulk imports need not hold every record in memory. opts.MaxBytes still
+// bounds the whole body. Errors for an element, from decoding or from fn, are
+// prefixed with its zero-based index; decoding errors unwrap to a *JSONError.
+func ReadJSONArray[T any](w http.ResponseWriter, r *http.Request, opts JSONReadOptions, fn func(item T) error) error {
+	maxBytes := opts.maxBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	dec := json.NewDecoder(r.Body)
+	if !opts.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return decodeError(err, maxBytes)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return &JSONError{Kind: JSONTypeMismatch, Offset: dec.InputOffset(), Err: errors.New("json: body is not an array")}
+	}
+
+	for i := 0; dec.More(); i++ {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return fmt.Errorf("item %d: %w", i, decodeError(err, maxBytes))
+		}
+		if err := fn(item); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+
+	// Consume the closing bracket; running out of input here means it was missing.
+	if _, err := dec.Token(); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return decodeError(err, maxBytes)
+	}
+
+	if opts.AllowMultipleValues {
+		return nil
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return &JSONError{Kind: JSONMultipleValues, Err: err}
+	}
+
+	return nil
+}
+
+// decodeError maps a json.Decoder error to a *JSONError where it can be classified.
+func decodeError(err error, maxBytes int64) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		return &JSONError{Kind: JSONSyntax, Offset: syntaxError.Offset, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &JSONError{Kind: JSONSyntax, Offset: -1, Err: err}
+	case errors.As(err, &unmarshalTypeError):
+		return &JSONError{Kind: JSONTypeMismatch, Field: unmarshalTypeError.Field, Offset: unmarshalTypeError.Offset, Err: err}
+	case errors.Is(err, io.EOF):
+		return &JSONError{Kind: JSONEmpty, Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return &JSONError{Kind: JSONUnknownField, Field: strings.Trim(fieldName, `"`), Err: err}
+	case err.Error() == "http: request body too large":
+		return &JSONError{Kind: JSONTooLarge, Limit: maxBytes, Err: err}
+	case errors.As(err, &invalidUnmarshalError):
+		panic(err)
+	default:
+		return err
+	}
+}
+
 // ReadString read string value from request
 func ReadString(r *http.Request, key string, defaultValue string) (string, bool, error) {
 	value, exists, err := ReadParam(r, key)
@@ -183,3 +350,54 @@ func ReadFloat(r *http.Request, key string, defaultValue float64) (float64, bool
 
 	return val, true, nil
 }
+
+// Integer is the constraint accepted by ReadIntParam.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// ReadIntParam reads the URL parameter key as a T, failing with an invalid parameter
+// error when it is not an integer or does not fit in T.
+func ReadIntParam[T Integer](r *http.Request, key string) (T, error) {
+	var zero T
+	s, _, err := ReadParam(r, key)
+	if err != nil {
+		return zero, err
+	}
+
+	bits := reflect.TypeOf(zero).Bits()
+	if neg := zero - 1; neg < 0 {
+		n, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return zero, fmt.Errorf(errInvalidParamText, key)
+		}
+		return T(n), nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return zero, fmt.Errorf(errInvalidParamText, key)
+	}
+
+	return T(n), nil
+}
+
+// ReadIDParamByKey reads a positive int64 id from the URL parameter key, for routes
+// such as /orgs/{orgID}/users/{userID}.
+func ReadIDParamByKey(r *http.Request, key string) (int64, error) {
+	id, err := ReadIntParam[int64](r, key)
+	if err != nil {
+		return 0, err
+	}
+	if id < 1 {
+		return 0, fmt.Errorf(errInvalidParamText, key)
+	}
+
+	return id, nil
+}
+
+// ReadIDParam reads a positive int64 id from the "id" URL parameter.
+func ReadIDParam(r *http.Request) (int64, error) {
+	return ReadIDParamByKey(r, "id")
+}
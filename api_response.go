@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"net/http"
+)
+
+// WriteJSON marshals data and writes it with the given status. Any headers are set on
+// the response, replacing existing values, then Content-Type is set to
+// application/json unless headers has one, e.g. for a JSON based media type. Nothing
+// is written if marshalling fails.
+func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	js, err := data.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// Append a newline to make it easier to view in terminal applications.
+	js = append(js, '\n')
+
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	if headers.Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	_, err = w.Write(js)
+
+	return err
+}
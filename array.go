@@ -1,6 +1,8 @@
 package helpers
 
-func InArray[T string | int](subset []T, allSet []T, checkAll bool) bool {
+// InArray reports whether any element of subset is in allSet or, with checkAll,
+// whether every element is. Contains is clearer for a single value.
+func InArray[T comparable](subset []T, allSet []T, checkAll bool) bool {
 	count := 0
 	for _, v := range allSet {
 		for _, s := range subset {
@@ -15,3 +17,160 @@ func InArray[T string | int](subset []T, allSet []T, checkAll bool) bool {
 
 	return count == len(subset)
 }
+
+// Contains reports whether v is in s.
+func Contains[T comparable](s []T, v T) bool {
+	return IndexOf(s, v) >= 0
+}
+
+// IndexOf returns the index of the first occurrence of v in s, or -1.
+func IndexOf[T comparable](s []T, v T) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Map returns the result of fn for each element of s.
+func Map[T, U any](s []T, fn func(T) U) []U {
+	out := make([]U, len(s))
+	for i, v := range s {
+		out[i] = fn(v)
+	}
+
+	return out
+}
+
+// Filter returns the elements of s for which keep returns true, in order.
+func Filter[T any](s []T, keep func(T) bool) []T {
+	var out []T
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// Reduce folds s into a single value, starting from init.
+func Reduce[T, U any](s []T, init U, fn func(acc U, v T) U) U {
+	acc := init
+	for _, v := range s {
+		acc = fn(acc, v)
+	}
+
+	return acc
+}
+
+// Unique returns s without duplicates, keeping the first occurrence of each value.
+func Unique[T comparable](s []T) []T {
+	seen := make(map[T]struct{}, len(s))
+	out := make([]T, 0, len(s))
+	for _, v := range s {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+
+	return out
+}
+
+// Chunk splits s into slices of at most size elements, e.g. for batched inserts. The
+// chunks share s's backing array. It panics if size is less than 1.
+func Chunk[T any](s []T, size int) [][]T {
+	if size < 1 {
+		panic("helpers: Chunk size must be at least 1")
+	}
+
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for size < len(s) {
+		chunks = append(chunks, s[:size:size])
+		s = s[size:]
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+
+	return chunks
+}
+
+// Reverse returns a reversed copy of s.
+func Reverse[T any](s []T) []T {
+	out := make([]T, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+
+	return out
+}
+
+// Flatten concatenates the slices of s.
+func Flatten[T any](s [][]T) []T {
+	n := 0
+	for _, inner := range s {
+		n += len(inner)
+	}
+
+	out := make([]T, 0, n)
+	for _, inner := range s {
+		out = append(out, inner...)
+	}
+
+	return out
+}
+
+// Intersect returns the values present in both a and b, in their order in a and
+// without duplicates.
+func Intersect[T comparable](a, b []T) []T {
+	inB := make(map[T]struct{}, len(b))
+	for _, v := range b {
+		inB[v] = struct{}{}
+	}
+
+	var out []T
+	for _, v := range a {
+		if _, ok := inB[v]; ok {
+			out = append(out, v)
+			// Drop v so a repeated value in a is only added once.
+			delete(inB, v)
+		}
+	}
+
+	return out
+}
+
+// Union returns the values present in a or b without duplicates: those of a in
+// order, then those only in b in order.
+func Union[T comparable](a, b []T) []T {
+	out := make([]T, 0, len(a)+len(b))
+	out = append(out, a...)
+
+	return Unique(append(out, b...))
+}
+
+// Difference returns the values of a that are not in b, in order and without
+// duplicates. Difference(required, granted) is empty when every required
+// permission is granted.
+func Difference[T comparable](a, b []T) []T {
+	seen := make(map[T]struct{}, len(a)+len(b))
+	for _, v := range b {
+		seen[v] = struct{}{}
+	}
+
+	var out []T
+	for _, v := range a {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+
+	return out
+}
@@ -0,0 +1,86 @@
+package barcode
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidContent = errors.New("barcode: content has characters Code 128 cannot encode")
+)
+
+// code128Patterns are the bar and space widths of each symbol value; 103 to 105 are
+// the start codes and 106 the stop code.
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128 encodes printable ASCII content as a Code 128 barcode, using code set C
+// for even-length digit strings, which halves their width, and code set B
+// otherwise. It returns one entry per module, true for a bar.
+func Code128(content string) ([]bool, error) {
+	if content == "" {
+		return nil, ErrInvalidContent
+	}
+
+	var values []int
+	if isDigits(content) && len(content)%2 == 0 {
+		values = append(values, code128StartC)
+		for i := 0; i < len(content); i += 2 {
+			values = append(values, int(content[i]-'0')*10+int(content[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(content); i++ {
+			c := content[i]
+			if c < 32 || c > 126 {
+				return nil, ErrInvalidContent
+			}
+			values = append(values, int(c)-32)
+		}
+	}
+
+	check := values[0]
+	for i, v := range values[1:] {
+		check += (i + 1) * v
+	}
+	values = append(values, check%103, code128Stop)
+
+	var modules []bool
+	for _, v := range values {
+		bar := true
+		for _, w := range code128Patterns[v] {
+			for k := 0; k < int(w-'0'); k++ {
+				modules = append(modules, bar)
+			}
+			bar = !bar
+		}
+	}
+
+	return modules, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,518 @@
+// Package barcode generates QR codes and Code 128 barcodes and renders them as PNG
+// or SVG, without external dependencies.
+package barcode
+
+import (
+	"errors"
+)
+
+var (
+	ErrTooLong = errors.New("barcode: content is too long")
+)
+
+// Level is the QR error correction level: the share of the symbol that can be
+// damaged and still read. The zero Level is LevelM.
+type Level int
+
+const (
+	LevelL Level = iota + 1 // 7%
+	LevelM                  // 15%
+	LevelQ                  // 25%
+	LevelH                  // 30%
+)
+
+// formatBits are the level bits used in the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l-1]
+}
+
+// Matrix is a grid of modules; true is dark.
+type Matrix struct {
+	Size    int
+	modules []bool
+}
+
+func (m *Matrix) At(x, y int) bool {
+	return m.modules[y*m.Size+x]
+}
+
+func (m *Matrix) set(x, y int, dark bool) {
+	m.modules[y*m.Size+x] = dark
+}
+
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// rawDataModules is the number of modules of a version available for data and
+// error correction, after the function patterns.
+func rawDataModules(ver int) int {
+	n := (16*ver+128)*ver + 64
+	if ver >= 2 {
+		align := ver/7 + 2
+		n -= (25*align-10)*align - 55
+		if ver >= 7 {
+			n -= 36
+		}
+	}
+
+	return n
+}
+
+func dataCodewords(ver int, level Level) int {
+	return rawDataModules(ver)/8 - eccCodewordsPerBlock[level-1][ver]*numErrorCorrectionBlocks[level-1][ver]
+}
+
+// QR encodes content in byte mode at the smallest version that fits. It returns
+// ErrTooLong past the capacity of version 40, 2953 bytes at LevelL.
+func QR(content string, level Level) (*Matrix, error) {
+	if level < LevelL || level > LevelH {
+		level = LevelM
+	}
+	data := []byte(content)
+
+	ver := 1
+	for ; ver <= 40; ver++ {
+		countBits := 8
+		if ver >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= dataCodewords(ver, level)*8 {
+			break
+		}
+	}
+	if ver > 40 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, ver, level), ver, level)
+
+	q := newQRBuilder(ver)
+	q.drawFunctionPatterns()
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(level, mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		// Masking is its own inverse.
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormatBits(level, best)
+
+	return &q.Matrix, nil
+}
+
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+func (b *bitBuffer) append(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// encodeData builds the data codewords: mode, length, content, terminator and pad.
+func encodeData(data []byte, ver int, level Level) []byte {
+	capacity := dataCodewords(ver, level) * 8
+
+	var b bitBuffer
+	b.append(0x4, 4) // byte mode
+	if ver >= 10 {
+		b.append(len(data), 16)
+	} else {
+		b.append(len(data), 8)
+	}
+	for _, c := range data {
+		b.append(int(c), 8)
+	}
+
+	term := capacity - b.n
+	if term > 4 {
+		term = 4
+	}
+	b.append(0, term)
+	if b.n%8 != 0 {
+		b.append(0, 8-b.n%8)
+	}
+	for pad := 0xEC; b.n < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+
+	return b.bytes
+}
+
+// addErrorCorrection splits data into blocks, appends the Reed-Solomon codewords of
+// each and interleaves them.
+func addErrorCorrection(data []byte, ver int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level-1][ver]
+	eccLen := eccCodewordsPerBlock[level-1][ver]
+	raw := rawDataModules(ver) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		dat := data[k : k+n]
+		k += n
+		block := make([]byte, 0, shortLen+1)
+		block = append(block, dat...)
+		if i < numShort {
+			// Placeholder so every block has the same length while interleaving.
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, rsRemainder(dat, divisor)...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i < shortLen+1; i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+
+	return out
+}
+
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>i&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+
+	return result
+}
+
+type qrBuilder struct {
+	Matrix
+	ver        int
+	isFunction []bool
+}
+
+func newQRBuilder(ver int) *qrBuilder {
+	size := ver*4 + 17
+	return &qrBuilder{
+		Matrix:     Matrix{Size: size, modules: make([]bool, size*size)},
+		ver:        ver,
+		isFunction: make([]bool, size*size),
+	}
+}
+
+func (q *qrBuilder) setFunction(x, y int, dark bool) {
+	q.set(x, y, dark)
+	q.isFunction[y*q.Size+x] = true
+}
+
+func (q *qrBuilder) drawFunctionPatterns() {
+	for i := 0; i < q.Size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	q.drawFinder(3, 3)
+	q.drawFinder(q.Size-4, 3)
+	q.drawFinder(3, q.Size-4)
+
+	pos := q.alignmentPositions()
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			q.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once the mask is known.
+	q.drawFormatBits(LevelM, 0)
+	q.drawVersion()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func chebyshev(dx, dy int) int {
+	if abs(dx) > abs(dy) {
+		return abs(dx)
+	}
+	return abs(dy)
+}
+
+func (q *qrBuilder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.Size || yy < 0 || yy >= q.Size {
+				continue
+			}
+			d := chebyshev(dx, dy)
+			q.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+func (q *qrBuilder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(x+dx, y+dy, chebyshev(dx, dy) != 1)
+		}
+	}
+}
+
+func (q *qrBuilder) alignmentPositions() []int {
+	if q.ver == 1 {
+		return nil
+	}
+
+	n := q.ver/7 + 2
+	step := 26
+	if q.ver != 32 {
+		step = (q.ver*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, q.Size-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+
+	return pos
+}
+
+func (q *qrBuilder) drawFormatBits(level Level, mask int) {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.Size-15+i, bit(i))
+	}
+	q.setFunction(8, q.Size-8, true)
+}
+
+func (q *qrBuilder) drawVersion() {
+	if q.ver < 7 {
+		return
+	}
+
+	rem := q.ver
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := q.ver<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := q.Size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order, two columns at a time from
+// the bottom right.
+func (q *qrBuilder) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if q.isFunction[y*q.Size+x] || i >= len(data)*8 {
+					continue
+				}
+				q.set(x, y, data[i/8]>>(7-i%8)&1 == 1)
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrBuilder) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y*q.Size+x] {
+				q.set(x, y, !q.At(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, following the four rules of the
+// QR specification. Lower is better.
+func (q *qrBuilder) penalty() int {
+	n := q.Size
+	score := 0
+
+	line := func(get func(i int) bool) {
+		run := 0
+		for i := 0; i < n; i++ {
+			if i > 0 && get(i) == get(i-1) {
+				run++
+			} else {
+				run = 1
+			}
+			if run == 5 {
+				score += 3
+			} else if run > 5 {
+				score++
+			}
+		}
+		// Finder-like 1:1:3:1:1 patterns with four light modules on one side.
+		pattern := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= n; i++ {
+			match := true
+			for k, p := range pattern {
+				if get(i+k) != p {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+			light := func(from, to int) bool {
+				for k := from; k < to; k++ {
+					if k >= 0 && k < n && get(k) {
+						return false
+					}
+				}
+				return true
+			}
+			if light(i-4, i) || light(i+7, i+11) {
+				score += 40
+			}
+		}
+	}
+	for y := 0; y < n; y++ {
+		line(func(i int) bool { return q.At(i, y) })
+	}
+	for x := 0; x < n; x++ {
+		line(func(i int) bool { return q.At(x, i) })
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			c := q.At(x, y)
+			if c {
+				dark++
+			}
+			if x+1 < n && y+1 < n && c == q.At(x+1, y) && c == q.At(x, y+1) && c == q.At(x+1, y+1) {
+				score += 3
+			}
+		}
+	}
+
+	percent := dark * 100 / (n * n)
+	score += abs(percent-50) / 5 * 10
+
+	return score
+}
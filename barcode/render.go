@@ -0,0 +1,181 @@
+package barcode
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// quietZone is the light margin, in modules, that scanners need around a QR code;
+// Code 128 needs ten.
+const quietZone = 4
+
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+var palette = color.Palette{color.White, color.Black}
+
+// PNG renders the code at size pixels square, or the nearest smaller multiple of the
+// module count, with at least one pixel per module.
+func (m *Matrix) PNG(w io.Writer, size int) error {
+	n := m.Size + 2*quietZone
+	scale := size / n
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, n*scale, n*scale), palette)
+	for y := 0; y < m.Size; y++ {
+		for x := 0; x < m.Size; x++ {
+			if !m.At(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG renders the code as a single path, size pixels square.
+func (m *Matrix) SVG(w io.Writer, size int) error {
+	n := m.Size + 2*quietZone
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y := 0; y < m.Size; y++ {
+		for x := 0; x < m.Size; x++ {
+			if m.At(x, y) {
+				fmt.Fprintf(bw, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+	bw.WriteString(`"/></svg>`)
+
+	return bw.Flush()
+}
+
+// Code128PNG renders the modules returned by Code128, width pixels wide or the
+// nearest smaller multiple of the module count.
+func Code128PNG(w io.Writer, modules []bool, width, height int) error {
+	n := len(modules) + 20
+	scale := width / n
+	if scale < 1 {
+		scale = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, n*scale, height), palette)
+	for i, bar := range modules {
+		if !bar {
+			continue
+		}
+		for dx := 0; dx < scale; dx++ {
+			for y := 0; y < height; y++ {
+				img.SetColorIndex((i+10)*scale+dx, y, 1)
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// Code128SVG renders the modules returned by Code128 as an SVG of the given size.
+func Code128SVG(w io.Writer, modules []bool, width, height int) error {
+	n := len(modules) + 20
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d 1" preserveAspectRatio="none" shape-rendering="crispEdges">`, width, height, n)
+	fmt.Fprintf(bw, `<rect width="%d" height="1" fill="#fff"/><path fill="#000" d="`, n)
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(modules) && modules[j] {
+			j++
+		}
+		fmt.Fprintf(bw, "M%d 0h%dv1h-%dz", i+10, j-i, j-i)
+		i = j
+	}
+	bw.WriteString(`"/></svg>`)
+
+	return bw.Flush()
+}
+
+type Options struct {
+	// Format defaults to PNG.
+	Format Format
+	// Level is the QR error correction level. Defaults to LevelM.
+	Level Level
+	// CacheControl defaults to "private, max-age=86400", since the same content
+	// always gives the same image. Use "no-store" for secrets such as TOTP
+	// provisioning URIs.
+	CacheControl string
+}
+
+// WriteQRCode sends content as a PNG QR code size pixels square.
+func WriteQRCode(w http.ResponseWriter, content string, size int) error {
+	return WriteQR(w, content, size, Options{})
+}
+
+// WriteQR sends content as a QR code in the format and level of opts.
+func WriteQR(w http.ResponseWriter, content string, size int, opts Options) error {
+	m, err := QR(content, opts.Level)
+	if err != nil {
+		return err
+	}
+
+	return writeImage(w, opts, func(w io.Writer) error {
+		if opts.Format == SVG {
+			return m.SVG(w, size)
+		}
+		return m.PNG(w, size)
+	})
+}
+
+// WriteCode128 sends content as a Code 128 barcode of the given size.
+func WriteCode128(w http.ResponseWriter, content string, width, height int, opts Options) error {
+	modules, err := Code128(content)
+	if err != nil {
+		return err
+	}
+
+	return writeImage(w, opts, func(w io.Writer) error {
+		if opts.Format == SVG {
+			return Code128SVG(w, modules, width, height)
+		}
+		return Code128PNG(w, modules, width, height)
+	})
+}
+
+func writeImage(w http.ResponseWriter, opts Options, render func(w io.Writer) error) error {
+	contentType := "image/png"
+	if opts.Format == SVG {
+		contentType = "image/svg+xml"
+	}
+	cacheControl := opts.CacheControl
+	if cacheControl == "" {
+		cacheControl = "private, max-age=86400"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	return render(w)
+}
@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BatchOptions struct {
+	// MaxRequests caps the sub-requests per batch. Defaults to 20.
+	MaxRequests int
+	// Concurrency caps how many sub-requests run at once. Defaults to 4.
+	Concurrency int
+	// ForwardHeaders are copied from the batch request to every sub-request unless the
+	// sub-request sets them itself. Defaults to Authorization, Cookie and
+	// Accept-Language.
+	ForwardHeaders []string
+}
+
+// BatchRequest is one entry of a batch. Body is passed through as JSON.
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is the outcome of one sub-request. Body is inlined when it is JSON and
+// carried as a string otherwise.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// BatchHandler accepts a JSON array of sub-requests, dispatches each through router
+// (typically the chi router the handler is mounted on) and responds with an array of
+// sub-responses in the same order:
+//
+//	r.Post("/v1/batch", helpers.BatchHandler(r, helpers.BatchOptions{}).ServeHTTP)
+//
+// Sub-requests run with the batch request's context, and cannot themselves be
+// batches, under whatever path the handler is mounted.
+func BatchHandler(router http.Handler, opts BatchOptions) http.Handler {
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = 20
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.ForwardHeaders == nil {
+		opts.ForwardHeaders = []string{"Authorization", "Cookie", "Accept-Language"}
+	}
+
+	badRequest := func(w http.ResponseWriter, msg string) {
+		WriteJSON(w, http.StatusBadRequest, Envelope{"error": msg}, nil)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(batchKey{}) != nil {
+			badRequest(w, "batches cannot be nested")
+			return
+		}
+
+		var reqs []BatchRequest
+		if err := ReadJSON(w, r, &reqs); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		if len(reqs) == 0 {
+			badRequest(w, "batch must contain at least one request")
+			return
+		}
+		if len(reqs) > opts.MaxRequests {
+			badRequest(w, fmt.Sprintf("batch must not contain more than %d requests", opts.MaxRequests))
+			return
+		}
+		for i, sub := range reqs {
+			if !strings.HasPrefix(sub.Path, "/") {
+				badRequest(w, fmt.Sprintf("request %d: path must start with /", i))
+				return
+			}
+		}
+
+		responses := make([]BatchResponse, len(reqs))
+		sem := make(chan struct{}, opts.Concurrency)
+		var wg sync.WaitGroup
+		for i := range reqs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				responses[i] = dispatchBatch(router, r, reqs[i], opts.ForwardHeaders)
+			}(i)
+		}
+		wg.Wait()
+
+		WriteJSON(w, http.StatusOK, Envelope{"responses": responses}, nil)
+	})
+}
+
+// batchKey marks the context of sub-requests, so a batch inside a batch is refused.
+type batchKey struct{}
+
+func dispatchBatch(router http.Handler, parent *http.Request, sub BatchRequest, forward []string) (resp BatchResponse) {
+	req := parent
+	defer func() {
+		if p := recover(); p != nil {
+			rec := &batchRecorder{header: http.Header{}, status: http.StatusOK}
+			ServerErrorResponse(rec, req, fmt.Errorf("batch sub-request panicked: %v", p))
+			resp = rec.response()
+		}
+	}()
+
+	method := strings.ToUpper(sub.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// Clear the batch request's route context so the router matches the sub-request
+	// from the top instead of treating it as a mounted sub-router.
+	ctx := context.WithValue(parent.Context(), chi.RouteCtxKey, nil)
+	ctx = context.WithValue(ctx, batchKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, method, sub.Path, bytes.NewReader(sub.Body))
+	if err != nil {
+		return BatchResponse{Status: http.StatusBadRequest, Body: map[string]string{"error": err.Error()}}
+	}
+	req.RemoteAddr = parent.RemoteAddr
+	req.Host = parent.Host
+	req.RequestURI = sub.Path
+	for _, h := range forward {
+		if v := parent.Header.Values(h); len(v) > 0 {
+			req.Header[http.CanonicalHeaderKey(h)] = v
+		}
+	}
+	for k, v := range sub.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(sub.Body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := &batchRecorder{header: http.Header{}, status: http.StatusOK}
+	router.ServeHTTP(rec, req)
+
+	return rec.response()
+}
+
+func (rec *batchRecorder) response() BatchResponse {
+	resp := BatchResponse{Status: rec.status}
+	if len(rec.header) > 0 {
+		resp.Headers = make(map[string]string, len(rec.header))
+		for k := range rec.header {
+			resp.Headers[k] = rec.header.Get(k)
+		}
+	}
+	if body := bytes.TrimSpace(rec.body.Bytes()); len(body) > 0 {
+		if json.Valid(body) && strings.Contains(rec.header.Get("Content-Type"), "json") {
+			resp.Body = json.RawMessage(body)
+		} else {
+			resp.Body = string(body)
+		}
+	}
+
+	return resp
+}
+
+// batchRecorder collects a sub-response in memory.
+type batchRecorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
@@ -0,0 +1,350 @@
+package cache
+
+import (
+	"container/list"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hasahmad/go-helpers/clock"
+)
+
+type Options struct {
+	// DefaultTTL applies to entries added with Set. Zero means entries never expire.
+	DefaultTTL time.Duration
+	// MaxSize caps the number of entries. Once reached, the oldest entry is evicted to
+	// make room. Zero means unbounded.
+	MaxSize int
+	// CleanupInterval is how often expired entries are purged in the background. Zero
+	// disables the background sweep; expired entries are then only dropped on access.
+	CleanupInterval time.Duration
+	// StaleWhileRevalidate keeps expired entries around for this long. During that
+	// window GetOrLoad returns the stale value immediately and refreshes it in the
+	// background, so an expiring key never blocks callers on the loader.
+	StaleWhileRevalidate time.Duration
+	// EarlyExpirationBeta enables probabilistic early recomputation ("XFetch") in
+	// GetOrLoad: the closer an entry is to expiry and the slower its loader, the more
+	// likely a caller refreshes it in the background ahead of time. 1.0 is a sensible
+	// value; larger values refresh earlier. Zero disables it.
+	EarlyExpirationBeta float64
+	// Clock is the time source. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// Stats reports cache usage counters since the cache was created.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	StaleHits   uint64
+	Evictions   uint64
+	Expirations uint64
+	Size        int
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+	// delta is how long the loader took to produce value, used for early expiration.
+	delta time.Duration
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// Cache is a concurrency-safe in-memory cache with per-entry TTLs.
+type Cache[K comparable, V any] struct {
+	opts  Options
+	clock clock.Clock
+
+	mu         sync.Mutex
+	items      map[K]*list.Element
+	order      *list.List
+	refreshing map[K]struct{}
+
+	hits        uint64
+	misses      uint64
+	staleHits   uint64
+	evictions   uint64
+	expirations uint64
+
+	loads Group[K, V]
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// New creates a Cache. When opts.CleanupInterval is set a background goroutine purges
+// expired entries until Close is called.
+func New[K comparable, V any](opts Options) *Cache[K, V] {
+	c := &Cache[K, V]{
+		opts:       opts,
+		clock:      clock.Or(opts.Clock),
+		items:      make(map[K]*list.Element),
+		order:      list.New(),
+		refreshing: make(map[K]struct{}),
+		stop:       make(chan struct{}),
+	}
+
+	if opts.CleanupInterval > 0 {
+		go c.janitor(opts.CleanupInterval)
+	}
+
+	return c
+}
+
+func (c *Cache[K, V]) janitor(interval time.Duration) {
+	ticker := c.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background sweep. The cache remains usable.
+func (c *Cache[K, V]) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+}
+
+// dead reports whether e is past its expiry and any stale-while-revalidate window.
+func (c *Cache[K, V]) dead(e *entry[K, V], now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires.Add(c.opts.StaleWhileRevalidate))
+}
+
+// Get returns the value for key if present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	now := c.clock.Now()
+	if c.dead(e, now) {
+		c.removeElement(el)
+		atomic.AddUint64(&c.expirations, 1)
+	}
+	if e.expired(now) {
+		atomic.AddUint64(&c.misses, 1)
+		return zero, false
+	}
+
+	atomic.AddUint64(&c.hits, 1)
+	return e.value, true
+}
+
+// Set stores value under key using the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.DefaultTTL)
+}
+
+// SetWithTTL stores value under key for ttl. A ttl of zero means the entry never expires.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.set(key, value, ttl, 0)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl, delta time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.clock.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value = value
+		e.expires = expires
+		e.delta = delta
+		c.order.MoveToBack(el)
+		return
+	}
+
+	if c.opts.MaxSize > 0 {
+		for c.order.Len() >= c.opts.MaxSize {
+			c.removeElement(c.order.Front())
+			atomic.AddUint64(&c.evictions, 1)
+		}
+	}
+
+	c.items[key] = c.order.PushBack(&entry[K, V]{key: key, value: value, expires: expires, delta: delta})
+}
+
+// GetOrLoad returns the cached value for key, calling loader and caching its result on
+// a miss. Concurrent misses for the same key share a single loader call. Errors from
+// loader are returned as-is and nothing is cached.
+//
+// With StaleWhileRevalidate set, an expired entry still inside the stale window is
+// returned straight away while a single background call refreshes it. With
+// EarlyExpirationBeta set, fresh entries may be refreshed in the background shortly
+// before they expire.
+func (c *Cache[K, V]) GetOrLoad(key K, loader func(key K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		v, now := e.value, c.clock.Now()
+
+		switch {
+		case !e.expired(now):
+			atomic.AddUint64(&c.hits, 1)
+			if c.earlyRefresh(e, now) {
+				c.refreshLocked(key, loader)
+			}
+			c.mu.Unlock()
+			return v, nil
+		case !c.dead(e, now):
+			atomic.AddUint64(&c.staleHits, 1)
+			c.refreshLocked(key, loader)
+			c.mu.Unlock()
+			return v, nil
+		}
+	}
+	atomic.AddUint64(&c.misses, 1)
+	c.mu.Unlock()
+
+	v, err, _ := c.loads.Do(key, func() (V, error) {
+		// Another caller may have filled the entry while we were queueing for the call.
+		if v, ok := c.peek(key); ok {
+			return v, nil
+		}
+
+		return c.load(key, loader)
+	})
+
+	return v, err
+}
+
+// earlyRefresh implements the XFetch test: refresh when
+// now - delta*beta*ln(rand()) >= expires.
+func (c *Cache[K, V]) earlyRefresh(e *entry[K, V], now time.Time) bool {
+	if c.opts.EarlyExpirationBeta <= 0 || e.expires.IsZero() || e.delta <= 0 {
+		return false
+	}
+
+	gap := -float64(e.delta) * c.opts.EarlyExpirationBeta * math.Log(rand.Float64())
+	return !now.Add(time.Duration(gap)).Before(e.expires)
+}
+
+// refreshLocked starts a background reload of key unless one is already running. The
+// caller must hold the mutex.
+func (c *Cache[K, V]) refreshLocked(key K, loader func(key K) (V, error)) {
+	if _, ok := c.refreshing[key]; ok {
+		return
+	}
+	c.refreshing[key] = struct{}{}
+
+	go func() {
+		c.loads.Do(key, func() (V, error) {
+			return c.load(key, loader)
+		})
+
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+}
+
+func (c *Cache[K, V]) load(key K, loader func(key K) (V, error)) (V, error) {
+	start := c.clock.Now()
+	v, err := loader(key)
+	if err != nil {
+		return v, err
+	}
+	c.set(key, v, c.opts.DefaultTTL, c.clock.Now().Sub(start))
+
+	return v, nil
+}
+
+// peek is Get without touching the hit/miss counters.
+func (c *Cache[K, V]) peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		if e := el.Value.(*entry[K, V]); !e.expired(c.clock.Now()) {
+			return e.value, true
+		}
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeleteExpired purges every expired entry which is also past its stale window.
+func (c *Cache[K, V]) DeleteExpired() {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if c.dead(el.Value.(*entry[K, V]), now) {
+			c.removeElement(el)
+			atomic.AddUint64(&c.expirations, 1)
+		}
+		el = next
+	}
+}
+
+// Clear removes every entry.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of entries, including expired ones not yet purged.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		StaleHits:   atomic.LoadUint64(&c.staleHits),
+		Evictions:   atomic.LoadUint64(&c.evictions),
+		Expirations: atomic.LoadUint64(&c.expirations),
+		Size:        c.Len(),
+	}
+}
+
+// removeElement drops el from the cache. The caller must hold the mutex.
+func (c *Cache[K, V]) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
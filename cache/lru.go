@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+type LRUOptions[K comparable, V any] struct {
+	// MaxEntries caps the number of entries. Zero means no entry limit.
+	MaxEntries int
+	// MaxCost caps the total cost of all entries, as reported by Cost. Zero means no
+	// cost limit.
+	MaxCost int64
+	// Cost returns the cost of an entry, typically its size in bytes. Defaults to 1
+	// per entry.
+	Cost func(key K, value V) int64
+	// OnEvict is called, outside the cache lock, for every entry removed to make room.
+	// It is not called for Delete or Clear.
+	OnEvict func(key K, value V)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// LRU is a concurrency-safe least-recently-used cache bounded by entry count, total
+// cost, or both.
+type LRU[K comparable, V any] struct {
+	opts LRUOptions[K, V]
+
+	mu    sync.Mutex
+	items map[K]*list.Element
+	order *list.List
+	cost  int64
+}
+
+func NewLRU[K comparable, V any](opts LRUOptions[K, V]) *LRU[K, V] {
+	if opts.Cost == nil {
+		opts.Cost = func(K, V) int64 { return 1 }
+	}
+
+	return &LRU[K, V]{
+		opts:  opts,
+		items: make(map[K]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Get returns the value for key and marks it as recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Peek returns the value for key without updating its recency.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Set adds or replaces key, evicting least recently used entries until the limits are
+// met again. An entry whose cost alone exceeds MaxCost is not stored, and any older
+// value for key is removed so it is not served in its place.
+func (c *LRU[K, V]) Set(key K, value V) {
+	cost := c.opts.Cost(key, value)
+
+	c.mu.Lock()
+	if c.opts.MaxCost > 0 && cost > c.opts.MaxCost {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+		c.mu.Unlock()
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[K, V])
+		c.cost += cost - e.cost
+		e.value = value
+		e.cost = cost
+		c.order.MoveToFront(el)
+	} else {
+		c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+		c.cost += cost
+	}
+
+	var evicted []*lruEntry[K, V]
+	for c.overLimit() {
+		el := c.order.Back()
+		c.remove(el)
+		evicted = append(evicted, el.Value.(*lruEntry[K, V]))
+	}
+	c.mu.Unlock()
+
+	if c.opts.OnEvict != nil {
+		for _, e := range evicted {
+			c.opts.OnEvict(e.key, e.value)
+		}
+	}
+}
+
+func (c *LRU[K, V]) overLimit() bool {
+	if c.order.Len() == 0 {
+		return false
+	}
+
+	return (c.opts.MaxEntries > 0 && c.order.Len() > c.opts.MaxEntries) ||
+		(c.opts.MaxCost > 0 && c.cost > c.opts.MaxCost)
+}
+
+// Delete removes key from the cache.
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Clear removes every entry.
+func (c *LRU[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+	c.cost = 0
+}
+
+// Len returns the number of entries.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Cost returns the total cost of all entries.
+func (c *LRU[K, V]) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cost
+}
+
+// Keys returns the keys from most to least recently used.
+func (c *LRU[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]K, 0, c.order.Len())
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*lruEntry[K, V]).key)
+	}
+
+	return keys
+}
+
+// remove drops el from the cache. The caller must hold the mutex.
+func (c *LRU[K, V]) remove(el *list.Element) {
+	e := el.Value.(*lruEntry[K, V])
+	c.order.Remove(el)
+	delete(c.items, e.key)
+	c.cost -= e.cost
+}
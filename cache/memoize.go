@@ -0,0 +1,13 @@
+package cache
+
+// Memoize wraps loader with a TTL cache and single-flight deduplication: concurrent
+// calls for the same uncached key trigger exactly one loader call and every caller
+// receives its result. Errors are not cached. The returned close stops the background
+// sweep started by opts.CleanupInterval; call it when the function is no longer used.
+func Memoize[K comparable, V any](loader func(key K) (V, error), opts Options) (load func(key K) (V, error), close func()) {
+	c := New[K, V](opts)
+
+	return func(key K) (V, error) {
+		return c.GetOrLoad(key, loader)
+	}, c.Close
+}
@@ -0,0 +1,309 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisClient is the subset of Redis commands used by RedisStore. The bundled client
+// returned by NewRedisClient implements it; wrap go-redis or similar to use a pooled
+// client you already have.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+}
+
+type RedisOptions struct {
+	// Prefix is prepended to every key, e.g. "myapp:cache:".
+	Prefix string
+	// Codec serializes values. Defaults to JSONCodec.
+	Codec Codec
+}
+
+// RedisStore is a Store backed by Redis.
+type RedisStore[V any] struct {
+	client RedisClient
+	opts   RedisOptions
+}
+
+func NewRedisStore[V any](client RedisClient, opts RedisOptions) *RedisStore[V] {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+
+	return &RedisStore[V]{client: client, opts: opts}
+}
+
+func (s *RedisStore[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var v V
+	data, ok, err := s.client.Get(ctx, s.opts.Prefix+key)
+	if err != nil || !ok {
+		return v, false, err
+	}
+
+	if err := s.opts.Codec.Unmarshal(data, &v); err != nil {
+		return v, false, err
+	}
+
+	return v, true, nil
+}
+
+func (s *RedisStore[V]) Set(ctx context.Context, key string, value V, ttl time.Duration) error {
+	data, err := s.opts.Codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, s.opts.Prefix+key, data, ttl)
+}
+
+func (s *RedisStore[V]) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.opts.Prefix+key)
+}
+
+type RedisClientOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize is the number of idle connections kept open. Defaults to 10.
+	PoolSize    int
+	DialTimeout time.Duration
+}
+
+// RedisConn is a minimal RESP2 client with a small idle connection pool. It supports the
+// commands needed by the helpers in this module and nothing more.
+type RedisConn struct {
+	opts RedisClientOptions
+	idle chan *poolConn
+}
+
+type poolConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+func NewRedisClient(opts RedisClientOptions) *RedisConn {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+
+	return &RedisConn{opts: opts, idle: make(chan *poolConn, opts.PoolSize)}
+}
+
+func (c *RedisConn) get(ctx context.Context) (*poolConn, error) {
+	select {
+	case rc := <-c.idle:
+		return rc, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	rc := &poolConn{conn: conn, rd: bufio.NewReader(conn)}
+
+	if c.opts.Password != "" {
+		if _, err := rc.do(ctx, "AUTH", c.opts.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := rc.do(ctx, "SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+func (c *RedisConn) put(rc *poolConn) {
+	select {
+	case c.idle <- rc:
+	default:
+		rc.conn.Close()
+	}
+}
+
+// Do sends a command and returns its reply: string, int64, []byte, []interface{} or nil.
+// Redis error replies are returned as errors.
+func (c *RedisConn) Do(ctx context.Context, args ...string) (interface{}, error) {
+	rc, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := rc.do(ctx, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		rc.conn.Close()
+		return nil, err
+	}
+	c.put(rc)
+
+	return reply, err
+}
+
+// Close closes every idle connection.
+func (c *RedisConn) Close() error {
+	for {
+		select {
+		case rc := <-c.idle:
+			rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisConn) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+
+	return b, true, nil
+}
+
+func (c *RedisConn) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms < 1 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+func (c *RedisConn) Del(ctx context.Context, keys ...string) error {
+	_, err := c.Do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (rc *poolConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		rc.conn.SetDeadline(deadline)
+	} else {
+		rc.conn.SetDeadline(time.Time{})
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	if _, err := rc.conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(rc.rd)
+}
+
+func readLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed reply")
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		// An error element is still followed by the rest of the array, which must be
+		// read to keep the connection usable.
+		items := make([]interface{}, n)
+		var first error
+		for i := range items {
+			item, err := readReply(rd)
+			var redisErr redisError
+			if err != nil && !errors.As(err, &redisErr) {
+				return nil, err
+			}
+			if err != nil && first == nil {
+				first = err
+			}
+			items[i] = item
+		}
+		if first != nil {
+			return nil, first
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+type call[V any] struct {
+	wg  sync.WaitGroup
+	val V
+	err error
+	dup int
+}
+
+// Group deduplicates concurrent calls for the same key: while a call is in flight,
+// later callers wait for it and share its result instead of starting their own.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// Do runs fn for key unless a call for key is already in flight, in which case it waits
+// for that call. shared reports whether the result was given to more than one caller.
+// A panic in fn is converted into an error for every waiter.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dup++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &call[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				c.err = fmt.Errorf("cache: loader panicked: %v", r)
+			}
+		}()
+		c.val, c.err = fn()
+	}()
+
+	g.mu.Lock()
+	// Forget may have let a newer call take the key; leave that one in place.
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	shared = c.dup > 0
+	g.mu.Unlock()
+	c.wg.Done()
+
+	return c.val, c.err, shared
+}
+
+// Forget makes the next Do for key start a new call even if one is in flight.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hasahmad/go-helpers/internal/msgpack"
+)
+
+// Store is the interface shared by the in-memory and Redis caches so calling code does
+// not need to know where values live.
+type Store[V any] interface {
+	Get(ctx context.Context, key string) (V, bool, error)
+	Set(ctx context.Context, key string, value V, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// GetOrLoad returns the value stored under key, calling loader and storing its result
+// for ttl on a miss. A failure to store the loaded value is not reported; the value is
+// still returned.
+func GetOrLoad[V any](ctx context.Context, s Store[V], key string, ttl time.Duration, loader func(ctx context.Context) (V, error)) (V, error) {
+	v, ok, err := s.Get(ctx, key)
+	if err == nil && ok {
+		return v, nil
+	}
+
+	v, err = loader(ctx)
+	if err != nil {
+		return v, err
+	}
+	_ = s.Set(ctx, key, v, ttl)
+
+	return v, nil
+}
+
+// Codec serializes values for stores which hold bytes.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// JSONCodec encodes values with encoding/json.
+var JSONCodec Codec = jsonCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+
+// MsgpackCodec encodes the JSON form of values as MessagePack, which is smaller than
+// JSON for numeric data. Values round-trip as they would through JSONCodec.
+var MsgpackCodec Codec = msgpackCodec{}
+
+// CodecFuncs adapts a pair of functions into a Codec, e.g. a CBOR library's
+// Marshal and Unmarshal.
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+func (c CodecFuncs) Marshal(v interface{}) ([]byte, error)      { return c.MarshalFunc(v) }
+func (c CodecFuncs) Unmarshal(data []byte, v interface{}) error { return c.UnmarshalFunc(data, v) }
+
+// MemoryStore adapts Cache to the Store interface.
+type MemoryStore[V any] struct {
+	cache *Cache[string, V]
+}
+
+func NewMemoryStore[V any](opts Options) *MemoryStore[V] {
+	return &MemoryStore[V]{cache: New[string, V](opts)}
+}
+
+func (s *MemoryStore[V]) Get(_ context.Context, key string) (V, bool, error) {
+	v, ok := s.cache.Get(key)
+	return v, ok, nil
+}
+
+func (s *MemoryStore[V]) Set(_ context.Context, key string, value V, ttl time.Duration) error {
+	s.cache.SetWithTTL(key, value, ttl)
+	return nil
+}
+
+func (s *MemoryStore[V]) Delete(_ context.Context, key string) error {
+	s.cache.Delete(key)
+	return nil
+}
+
+// Cache returns the underlying Cache, e.g. to read its Stats.
+func (s *MemoryStore[V]) Cache() *Cache[string, V] {
+	return s.cache
+}
@@ -0,0 +1,242 @@
+// Package clock abstracts time so that code depending on it can be driven by a fake
+// clock in tests.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the subset of the time package used by this module.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+// Or returns c, or Real when c is nil. It lets Options structs leave Clock unset.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a Clock that only moves when told to. Timers and tickers fire during
+// Advance or Set once their deadline is reached.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed *sync.Cond
+}
+
+type fakeWaiter struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration // non-zero for tickers
+	ch       chan time.Time
+	active   bool
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{clock: f, period: period, ch: make(chan time.Time, 1)}
+	f.schedule(w, d)
+	return w
+}
+
+// schedule arms w to fire d from now. The caller must hold the mutex.
+func (f *Fake) schedule(w *fakeWaiter, d time.Duration) {
+	w.deadline = f.now.Add(d)
+	if !w.active {
+		w.active = true
+		f.waiters = append(f.waiters, w)
+	}
+	if d <= 0 {
+		f.fire()
+	}
+	f.changed.Broadcast()
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker that comes due
+// on the way, in deadline order.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t. Moving it backwards fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		next := f.nextDue(t)
+		if next == nil {
+			break
+		}
+		if next.deadline.After(f.now) {
+			f.now = next.deadline
+		}
+		f.fire()
+	}
+	f.now = t
+}
+
+// nextDue returns the earliest active waiter due at or before t.
+func (f *Fake) nextDue(t time.Time) *fakeWaiter {
+	var next *fakeWaiter
+	for _, w := range f.waiters {
+		if !w.deadline.After(t) && (next == nil || w.deadline.Before(next.deadline)) {
+			next = w
+		}
+	}
+
+	return next
+}
+
+// fire delivers to every waiter due at the current time, rescheduling tickers. Like
+// the time package, a tick is dropped when the previous one was not received.
+func (f *Fake) fire() {
+	var keep []*fakeWaiter
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].deadline.Before(f.waiters[j].deadline) })
+
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			keep = append(keep, w)
+			continue
+		}
+
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+			keep = append(keep, w)
+		} else {
+			w.active = false
+		}
+	}
+	f.waiters = keep
+}
+
+// Waiters returns the number of pending timers and tickers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n timers or tickers are pending, so a test can be
+// sure the code under test is waiting before it calls Advance.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+func (w *fakeWaiter) Stop() bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !w.active {
+		return false
+	}
+	w.active = false
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			break
+		}
+	}
+	f.changed.Broadcast()
+	return true
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wasActive := w.active
+	f.schedule(w, d)
+	return wasActive
+}
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.Stop() }
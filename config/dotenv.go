@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DotEnvFiles returns the files read by LoadDotEnv for environment, lowest precedence
+// first: .env, .env.<environment>, .env.local.
+func DotEnvFiles(environment string) []string {
+	files := []string{".env"}
+	if environment != "" {
+		files = append(files, ".env."+environment)
+	}
+
+	return append(files, ".env.local")
+}
+
+// LoadDotEnv reads the dotenv files in dir for environment (see DotEnvFiles) and sets
+// every variable found that is not already present in the process environment.
+// Precedence, highest first, is therefore:
+//
+//  1. real environment variables
+//  2. .env.local
+//  3. .env.<environment>
+//  4. .env
+//
+// Missing files are skipped. .env.local is meant for per-developer overrides and
+// should not be committed.
+func LoadDotEnv(dir, environment string) error {
+	merged := make(map[string]string)
+	for _, name := range DotEnvFiles(environment) {
+		path := filepath.Join(dir, name)
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		vars, err := parseDotEnv(f, func(key string) (string, bool) {
+			if v, ok := os.LookupEnv(key); ok {
+				return v, true
+			}
+			v, ok := merged[key]
+			return v, ok
+		})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for k, v := range vars {
+			merged[k] = v
+		}
+	}
+
+	for k, v := range merged {
+		if _, exists := os.LookupEnv(k); exists {
+			continue
+		}
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseDotEnv parses dotenv syntax: KEY=value lines, optional "export " prefixes,
+// # comments, single-quoted literal values, double-quoted values with \n, \t, \" and
+// \\ escapes, and ${VAR} references to variables defined earlier in the same input.
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	return parseDotEnv(r, func(string) (string, bool) { return "", false })
+}
+
+func parseDotEnv(r io.Reader, lookup func(key string) (string, bool)) (map[string]string, error) {
+	vars := make(map[string]string)
+	expand := func(key string) string {
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		v, _ := lookup(key)
+		return v
+	}
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value", lineNo)
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			v, err := unquoteDouble(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			value = os.Expand(v, expand)
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			value = os.Expand(value, expand)
+		}
+
+		vars[key] = value
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func unquoteDouble(s string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String(), nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", errors.New("unterminated double-quoted value")
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors aggregates configuration problems so they can be reported in one go.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return "invalid configuration:\n  " + strings.Join(msgs, "\n  ")
+}
+
+// FieldError is a problem with a single configuration key.
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// EnvString returns the value of the environment variable key, or defaultValue if it
+// is unset or empty.
+func EnvString(key, defaultValue string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+
+	return defaultValue
+}
+
+// EnvRequired returns the value of the environment variable key, or an error if it is
+// unset or empty.
+func EnvRequired(key string) (string, error) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v, nil
+	}
+
+	return "", &FieldError{Key: key, Message: "must be set"}
+}
+
+// EnvInt reads an integer environment variable.
+func EnvInt(key string, defaultValue int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return defaultValue, &FieldError{Key: key, Message: "must be an integer value"}
+	}
+
+	return i, nil
+}
+
+// EnvBool reads a boolean environment variable, accepting the same forms as ReadBool
+// (true/t/y/1, false/f/n/0) plus yes/no and on/off.
+func EnvBool(key string, defaultValue bool) (bool, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return defaultValue, nil
+	}
+
+	b, ok := parseBool(v)
+	if !ok {
+		return defaultValue, &FieldError{Key: key, Message: "must be a boolean value"}
+	}
+
+	return b, nil
+}
+
+// EnvDuration reads a time.Duration environment variable such as "30s" or "5m".
+func EnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		return defaultValue, &FieldError{Key: key, Message: "must be a duration such as 30s or 5m"}
+	}
+
+	return d, nil
+}
+
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "t", "y", "yes", "on", "1":
+		return true, true
+	case "false", "f", "n", "no", "off", "0":
+		return false, true
+	}
+
+	return false, false
+}
+
+// Env reads typed environment variables and collects every failure instead of
+// stopping at the first one:
+//
+//	env := config.NewEnv()
+//	port := env.Int("PORT", 4000)
+//	dsn := env.Required("DB_DSN")
+//	if err := env.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+type Env struct {
+	errs Errors
+}
+
+func NewEnv() *Env {
+	return &Env{}
+}
+
+func (e *Env) collect(err error) {
+	if err != nil {
+		e.errs = append(e.errs, err)
+	}
+}
+
+func (e *Env) String(key, defaultValue string) string {
+	return EnvString(key, defaultValue)
+}
+
+func (e *Env) Required(key string) string {
+	v, err := EnvRequired(key)
+	e.collect(err)
+	return v
+}
+
+func (e *Env) Int(key string, defaultValue int) int {
+	v, err := EnvInt(key, defaultValue)
+	e.collect(err)
+	return v
+}
+
+func (e *Env) Bool(key string, defaultValue bool) bool {
+	v, err := EnvBool(key, defaultValue)
+	e.collect(err)
+	return v
+}
+
+func (e *Env) Duration(key string, defaultValue time.Duration) time.Duration {
+	v, err := EnvDuration(key, defaultValue)
+	e.collect(err)
+	return v
+}
+
+// Err returns every collected failure as Errors, or nil if there were none.
+func (e *Env) Err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+
+	return e.errs
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTarget = errors.New("config: destination must be a non-nil pointer to a struct")
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// fieldTag is a parsed `env:"NAME,default=...,required,secret"` tag.
+type fieldTag struct {
+	name       string
+	def        string
+	hasDefault bool
+	required   bool
+	secret     bool
+}
+
+func parseTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{name: parts[0]}
+
+	inDefault := false
+	for _, part := range parts[1:] {
+		switch {
+		case part == "required":
+			ft.required, inDefault = true, false
+		case part == "secret":
+			ft.secret, inDefault = true, false
+		case strings.HasPrefix(part, "default="):
+			ft.def, ft.hasDefault, inDefault = strings.TrimPrefix(part, "default="), true, true
+		case inDefault:
+			// Defaults for slices contain commas themselves.
+			ft.def += "," + part
+		}
+	}
+
+	return ft
+}
+
+// LoadConfig populates the struct pointed to by dst from environment variables using
+// `env` struct tags:
+//
+//	type Config struct {
+//		Port     int           `env:"PORT,default=4000"`
+//		Timeout  time.Duration `env:"TIMEOUT,default=30s"`
+//		Origins  []string      `env:"CORS_ORIGINS,default=a.com,b.com"`
+//		DB       struct {
+//			DSN      string `env:"DSN,required"`
+//			Password string `env:"PASSWORD,secret"`
+//		} `env:"DB"`
+//	}
+//
+// A tag on a nested struct is used as a prefix, so DB.DSN above is read from DB_DSN.
+// Supported field types are strings, bools, ints, uints, floats, time.Duration,
+// time.Time (RFC 3339), url.URL, pointers to these and slices of them (comma
+// separated). Every problem is collected and returned together as Errors.
+func LoadConfig(dst interface{}) error {
+	return LoadConfigFrom(dst, os.LookupEnv)
+}
+
+// LoadConfigFrom is LoadConfig reading variables through lookup instead of os.LookupEnv.
+func LoadConfigFrom(dst interface{}, lookup func(key string) (string, bool)) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+
+	var errs Errors
+	walkFields(rv.Elem(), "", func(f reflect.Value, key string, tag fieldTag) {
+		raw, ok := lookup(key)
+		if !ok || raw == "" {
+			if tag.required {
+				errs = append(errs, &FieldError{Key: key, Message: "must be set"})
+				return
+			}
+			if !tag.hasDefault {
+				return
+			}
+			raw = tag.def
+		}
+
+		if err := setField(f, raw); err != nil {
+			errs = append(errs, &FieldError{Key: key, Message: err.Error()})
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// walkFields calls fn for every tagged leaf field of v, recursing into nested structs
+// with their tag name as a prefix.
+func walkFields(v reflect.Value, prefix string, fn func(f reflect.Value, key string, tag fieldTag)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		raw, hasTag := sf.Tag.Lookup("env")
+		if raw == "-" {
+			continue
+		}
+		tag := parseTag(raw)
+		f := v.Field(i)
+
+		if sf.Type.Kind() == reflect.Struct && !isScalarStruct(sf.Type) {
+			nested := prefix
+			if tag.name != "" {
+				nested += tag.name + "_"
+			}
+			walkFields(f, nested, fn)
+			continue
+		}
+
+		if !hasTag || tag.name == "" {
+			continue
+		}
+		fn(f, prefix+tag.name, tag)
+	}
+}
+
+func isScalarStruct(t reflect.Type) bool {
+	return t == urlType || t == timeType
+}
+
+func setField(f reflect.Value, raw string) error {
+	if f.Kind() == reflect.Pointer {
+		v := reflect.New(f.Type().Elem())
+		if err := setField(v.Elem(), raw); err != nil {
+			return err
+		}
+		f.Set(v)
+		return nil
+	}
+
+	switch f.Type() {
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("must be a duration such as 30s or 5m")
+		}
+		f.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("must be a valid URL")
+		}
+		f.Set(reflect.ValueOf(*u))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("must be an RFC 3339 timestamp")
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Bool:
+		b, ok := parseBool(raw)
+		if !ok {
+			return errors.New("must be a boolean value")
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(raw), 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer value")
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(raw), 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer value")
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(strings.TrimSpace(raw), f.Type().Bits())
+		if err != nil {
+			return errors.New("must be a float value")
+		}
+		f.SetFloat(fl)
+	case reflect.Slice:
+		var parts []string
+		if strings.TrimSpace(raw) != "" {
+			parts = strings.Split(raw, ",")
+		}
+		s := reflect.MakeSlice(f.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(s.Index(i), strings.TrimSpace(part)); err != nil {
+				return fmt.Errorf("item %d %v", i, err)
+			}
+		}
+		f.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+
+	return nil
+}
+
+// String renders cfg as one KEY=value line per tagged field, replacing the values of
+// fields tagged `secret` with "REDACTED", so a loaded configuration can be logged.
+func String(cfg interface{}) string {
+	rv := reflect.ValueOf(cfg)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Sprint(cfg)
+	}
+
+	var b strings.Builder
+	walkFields(rv, "", func(f reflect.Value, key string, tag fieldTag) {
+		b.WriteString(key)
+		b.WriteByte('=')
+		if tag.secret {
+			if !f.IsZero() {
+				b.WriteString("REDACTED")
+			}
+		} else {
+			b.WriteString(formatValue(f))
+		}
+		b.WriteByte('\n')
+	})
+
+	return b.String()
+}
+
+func formatValue(f reflect.Value) string {
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			return ""
+		}
+		f = f.Elem()
+	}
+
+	switch f.Type() {
+	case durationType:
+		return time.Duration(f.Int()).String()
+	case urlType:
+		u := f.Interface().(url.URL)
+		return u.Redacted()
+	case timeType:
+		return f.Interface().(time.Time).Format(time.RFC3339)
+	}
+
+	if f.Kind() == reflect.Slice {
+		parts := make([]string, f.Len())
+		for i := range parts {
+			parts[i] = formatValue(f.Index(i))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	return fmt.Sprint(f.Interface())
+}
@@ -0,0 +1,355 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/internal/awsv4"
+)
+
+var (
+	ErrSecretNotFound = errors.New("config: secret not found")
+)
+
+// LoadSecret reads a secret following the Docker/Kubernetes *_FILE convention: if
+// NAME_FILE is set, the secret is the content of that file with surrounding whitespace
+// trimmed; otherwise it is the NAME environment variable.
+func LoadSecret(name string) (string, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("config: reading %s_FILE: %w", name, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	if v, ok := os.LookupEnv(name); ok && v != "" {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+}
+
+// Provider fetches secrets from an external store. Providers return an error wrapping
+// ErrSecretNotFound when they do not hold the secret.
+type Provider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+func (f ProviderFunc) GetSecret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// EnvProvider reads secrets with LoadSecret.
+var EnvProvider Provider = ProviderFunc(func(_ context.Context, name string) (string, error) {
+	return LoadSecret(name)
+})
+
+// VaultProvider reads secrets from a HashiCorp Vault KV version 2 engine. Names have
+// the form "path/to/secret#field"; without a field the secret must hold exactly one
+// key.
+type VaultProvider struct {
+	// Addr is the Vault address, e.g. https://vault:8200. Defaults to VAULT_ADDR.
+	Addr string
+	// Token authenticates requests. Defaults to VAULT_TOKEN.
+	Token string
+	// Mount is the KV engine mount. Defaults to "secret".
+	Mount  string
+	Client *http.Client
+}
+
+func (p *VaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	addr, token, mount := p.Addr, p.Token, p.Mount
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if mount == "" {
+		mount = "secret"
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	path, field, _ := strings.Cut(name, "#")
+	url := strings.TrimRight(addr, "/") + "/v1/" + mount + "/data/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("config: vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	return pickField(body.Data.Data, name, field)
+}
+
+// AWSSecretsManagerProvider reads secrets from AWS Secrets Manager. Names are secret
+// IDs or ARNs, optionally suffixed with "#field" to pick a key from a JSON secret.
+type AWSSecretsManagerProvider struct {
+	// Region defaults to AWS_REGION.
+	Region string
+	// Credentials default to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// AWS_SESSION_TOKEN.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint overrides the regional endpoint, e.g. for LocalStack.
+	Endpoint string
+	Client   *http.Client
+}
+
+func (p *AWSSecretsManagerProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	region := p.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	creds := awsv4.Credentials{AccessKeyID: p.AccessKeyID, SecretAccessKey: p.SecretAccessKey, SessionToken: p.SessionToken}
+	if creds.AccessKeyID == "" {
+		creds = awsv4.CredentialsFromEnv()
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com/"
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	id, field, _ := strings.Cut(name, "#")
+	payload, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	awsv4.Sign(req, awsv4.HashPayload(payload), creds, region, "secretsmanager", time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if bytes.Contains(data, []byte("ResourceNotFoundException")) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return "", fmt.Errorf("config: secrets manager returned %s for %s", resp.Status, id)
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", err
+	}
+	if field == "" {
+		return out.SecretString, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(out.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("config: secret %s is not a JSON object", id)
+	}
+
+	return pickField(fields, name, field)
+}
+
+func pickField(data map[string]interface{}, name, field string) (string, error) {
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("config: secret %s holds %d keys, pick one with #field", name, len(data))
+		}
+		for _, v := range data {
+			return fmt.Sprint(v), nil
+		}
+	}
+
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	return fmt.Sprint(v), nil
+}
+
+type SecretsOptions struct {
+	// Providers are tried in order until one holds the secret. Defaults to EnvProvider.
+	Providers []Provider
+	// TTL is how long fetched values are cached. Zero caches until Refresh.
+	TTL time.Duration
+}
+
+type cachedSecret struct {
+	value   string
+	fetched time.Time
+}
+
+// Secrets fetches secrets through a chain of providers, caches them and notifies
+// registered hooks when a refreshed value differs from the cached one, so e.g. a DB
+// pool can reconnect after a password rotation.
+type Secrets struct {
+	opts SecretsOptions
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+	hooks map[string][]func(value string)
+}
+
+func NewSecrets(opts SecretsOptions) *Secrets {
+	if len(opts.Providers) == 0 {
+		opts.Providers = []Provider{EnvProvider}
+	}
+
+	return &Secrets{
+		opts:  opts,
+		cache: make(map[string]cachedSecret),
+		hooks: make(map[string][]func(string)),
+	}
+}
+
+// Get returns the secret name, from the cache when fresh.
+func (s *Secrets) Get(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	c, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && (s.opts.TTL <= 0 || time.Since(c.fetched) < s.opts.TTL) {
+		return c.value, nil
+	}
+
+	value, err := s.fetch(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	s.store(name, value)
+
+	return value, nil
+}
+
+func (s *Secrets) fetch(ctx context.Context, name string) (string, error) {
+	for _, p := range s.opts.Providers {
+		v, err := p.GetSecret(ctx, name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+}
+
+// store caches value and runs the rotation hooks if it replaced a different value.
+func (s *Secrets) store(name, value string) {
+	s.mu.Lock()
+	old, existed := s.cache[name]
+	s.cache[name] = cachedSecret{value: value, fetched: time.Now()}
+	hooks := append([]func(string){}, s.hooks[name]...)
+	s.mu.Unlock()
+
+	if existed && old.value != value {
+		for _, hook := range hooks {
+			hook(value)
+		}
+	}
+}
+
+// OnRotate registers fn to be called with the new value whenever secret name changes.
+func (s *Secrets) OnRotate(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks[name] = append(s.hooks[name], fn)
+}
+
+// Refresh re-fetches every cached secret, running rotation hooks for changed values.
+// Errors are collected and returned together; failing secrets keep their old value.
+func (s *Secrets) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.cache))
+	for name := range s.cache {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	var errs Errors
+	for _, name := range names {
+		value, err := s.fetch(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.store(name, value)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Watch calls Refresh every interval until ctx is done. Refresh errors are passed to
+// onError if it is not nil.
+func (s *Secrets) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
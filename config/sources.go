@@ -0,0 +1,296 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+	SourceUnset   Source = "unset"
+)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]func(data []byte, v interface{}) error{
+		".json": json.Unmarshal,
+	}
+)
+
+// RegisterFileDecoder registers the decoder used for config files with the given
+// extension. JSON is built in; register YAML with e.g.
+//
+//	config.RegisterFileDecoder(".yaml", yaml.Unmarshal)
+//	config.RegisterFileDecoder(".yml", yaml.Unmarshal)
+//
+// The decoder must be able to decode into a map[string]interface{}.
+func RegisterFileDecoder(ext string, decode func(data []byte, v interface{}) error) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	decoders[strings.ToLower(ext)] = decode
+}
+
+type LoadOptions struct {
+	// Args are the command line arguments, without the program name. Defaults to
+	// os.Args[1:].
+	Args []string
+	// File is the default config file path, overridable with --config. Empty means no
+	// file unless --config is given. A missing file is an error.
+	File string
+	// Lookup reads environment variables. Defaults to os.LookupEnv.
+	Lookup func(key string) (string, bool)
+	// FlagSetName names the flag set in usage output. Defaults to os.Args[0].
+	FlagSetName string
+}
+
+// FieldInfo describes where the effective value of one field came from.
+type FieldInfo struct {
+	Env    string
+	Flag   string
+	Value  string
+	Source Source
+}
+
+// Result reports the outcome of Load.
+type Result struct {
+	Fields []FieldInfo
+	// PrintRequested is true when --print-config was passed. Callers should Print the
+	// result and exit.
+	PrintRequested bool
+}
+
+// Print writes the effective configuration, one field per line with its source.
+// Values of fields tagged `secret` are redacted.
+func (r *Result) Print(w io.Writer) {
+	for _, f := range r.Fields {
+		fmt.Fprintf(w, "%s=%s\t(%s)\n", f.Env, f.Value, f.Source)
+	}
+}
+
+// flagName derives the flag name for an env key, e.g. DB_DSN -> db-dsn.
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+type flagValue struct {
+	value  string
+	isBool bool
+	set    bool
+}
+
+func (v *flagValue) String() string   { return v.value }
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
+func (v *flagValue) Set(s string) error {
+	v.value, v.set = s, true
+	return nil
+}
+
+// Load populates dst from command line flags, environment variables and a JSON (or
+// registered format) config file, in that order of precedence, falling back to tag
+// defaults. Fields use the same `env` tags as LoadConfig; each also gets a flag named
+// after its env key (DB_DSN becomes --db-dsn) unless a `flag` tag overrides it, and is
+// looked up in the file under the lower-cased env key, with nested objects joined by
+// underscores ({"db": {"dsn": ...}} matches DB_DSN).
+//
+// Load also registers --config to pick the file and --print-config, which sets
+// Result.PrintRequested.
+func Load(dst interface{}, opts LoadOptions) (*Result, error) {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidTarget
+	}
+	if opts.Args == nil && len(os.Args) > 1 {
+		opts.Args = os.Args[1:]
+	}
+	if opts.Lookup == nil {
+		opts.Lookup = os.LookupEnv
+	}
+	if opts.FlagSetName == "" && len(os.Args) > 0 {
+		opts.FlagSetName = os.Args[0]
+	}
+
+	type field struct {
+		value reflect.Value
+		key   string
+		tag   fieldTag
+		flag  *flagValue
+		name  string
+	}
+
+	fs := flag.NewFlagSet(opts.FlagSetName, flag.ContinueOnError)
+	configPath := fs.String("config", opts.File, "path to a config file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+
+	var fields []field
+	walkFields(rv.Elem(), "", func(f reflect.Value, key string, tag fieldTag) {
+		fd := field{value: f, key: key, tag: tag, name: flagName(key)}
+		if sf, ok := structFieldByKey(rv.Elem(), key); ok {
+			if name := sf.Tag.Get("flag"); name != "" {
+				fd.name = name
+			}
+		}
+		base := f.Type()
+		if base.Kind() == reflect.Pointer {
+			base = base.Elem()
+		}
+		fd.flag = &flagValue{value: tag.def, isBool: base.Kind() == reflect.Bool}
+		fs.Var(fd.flag, fd.name, "sets "+key)
+		fields = append(fields, fd)
+	})
+
+	if err := fs.Parse(opts.Args); err != nil {
+		return nil, err
+	}
+
+	file := map[string]string{}
+	if *configPath != "" {
+		var err error
+		if file, err = readConfigFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{PrintRequested: *printConfig}
+	var errs Errors
+	for _, fd := range fields {
+		info := FieldInfo{Env: fd.key, Flag: fd.name, Source: SourceUnset}
+
+		raw, source := "", SourceUnset
+		if fd.flag.set {
+			raw, source = fd.flag.value, SourceFlag
+		} else if v, ok := opts.Lookup(fd.key); ok && v != "" {
+			raw, source = v, SourceEnv
+		} else if v, ok := file[strings.ToLower(fd.key)]; ok {
+			raw, source = v, SourceFile
+		} else if fd.tag.hasDefault {
+			raw, source = fd.tag.def, SourceDefault
+		}
+
+		if source == SourceUnset {
+			if fd.tag.required {
+				errs = append(errs, &FieldError{Key: fd.key, Message: fmt.Sprintf("must be set (flag --%s, env %s or config file)", fd.name, fd.key)})
+			}
+		} else if err := setField(fd.value, raw); err != nil {
+			errs = append(errs, &FieldError{Key: fd.key, Message: fmt.Sprintf("%v (from %s)", err, source)})
+		} else {
+			info.Source = source
+		}
+
+		if fd.tag.secret && !fd.value.IsZero() {
+			info.Value = "REDACTED"
+		} else {
+			info.Value = formatValue(fd.value)
+		}
+		res.Fields = append(res.Fields, info)
+	}
+
+	if len(errs) > 0 {
+		return res, errs
+	}
+
+	return res, nil
+}
+
+// structFieldByKey finds the struct field which walkFields reports under key.
+func structFieldByKey(v reflect.Value, key string) (reflect.StructField, bool) {
+	var found reflect.StructField
+	var ok bool
+
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField() && !ok; i++ {
+			sf := t.Field(i)
+			tag := parseTag(sf.Tag.Get("env"))
+			if sf.Type.Kind() == reflect.Struct && !isScalarStruct(sf.Type) {
+				nested := prefix
+				if tag.name != "" {
+					nested += tag.name + "_"
+				}
+				walk(sf.Type, nested)
+				continue
+			}
+			if tag.name != "" && prefix+tag.name == key {
+				found, ok = sf, true
+			}
+		}
+	}
+	walk(v.Type(), "")
+
+	return found, ok
+}
+
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decodersMu.RLock()
+	decode, ok := decoders[strings.ToLower(filepath.Ext(path))]
+	decodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config: no decoder registered for %s files", filepath.Ext(path))
+	}
+
+	var m map[string]interface{}
+	if err := decode(data, &m); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	flat := make(map[string]string)
+	flatten(flat, "", m)
+
+	return flat, nil
+}
+
+// flatten turns nested objects into lower-cased underscore-joined keys. Arrays become
+// comma separated values to match the env syntax.
+func flatten(dst map[string]string, prefix string, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := strings.ToLower(k)
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			flatten(dst, name, val[k])
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[fmt.Sprint(k)] = v
+		}
+		flatten(dst, prefix, m)
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = fmt.Sprint(item)
+		}
+		dst[prefix] = strings.Join(parts, ",")
+	case float64:
+		dst[prefix] = strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+	default:
+		dst[prefix] = fmt.Sprint(val)
+	}
+}
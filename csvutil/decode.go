@@ -0,0 +1,373 @@
+package csvutil
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hasahmad/go-helpers/timeutil"
+)
+
+var (
+	ErrMissingColumn = errors.New("csvutil: missing required column")
+	ErrUnknownColumn = errors.New("csvutil: unknown column")
+	ErrInvalidTarget = errors.New("csvutil: target must be a struct type")
+)
+
+type Options struct {
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+	// TrimSpace trims surrounding whitespace from every value.
+	TrimSpace bool
+	// MaxErrors stops reading once this many rows have failed. Zero means unlimited.
+	MaxErrors int
+	// DisallowUnknownColumns rejects headers that don't map to a field.
+	DisallowUnknownColumns bool
+	// TimeLayout parses time.Time fields. Empty accepts any layout understood by
+	// timeutil.ParseFlexibleTime.
+	TimeLayout string
+}
+
+// RowError is a problem with a single value. Row is the 1-based line number in the
+// file, counting the header.
+type RowError struct {
+	Row    int
+	Column string
+	Field  string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+
+	return fmt.Sprintf("row %d, column %q: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects the row errors of an import.
+type Errors []*RowError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return "csv import failed:\n  " + strings.Join(msgs, "\n  ")
+}
+
+type column struct {
+	name     string
+	index    []int
+	required bool
+}
+
+// Reader decodes CSV rows into T using `csv:"name"` struct tags. Headers are matched
+// case-insensitively, ignoring spaces, dashes and underscores, and may appear in any
+// order. Untagged fields match their field name; `csv:"-"` skips a field and
+// `csv:"name,required"` demands both the column and a non-empty value.
+type Reader[T any] struct {
+	r       *csv.Reader
+	opts    Options
+	headers []string
+	fields  []*column // by column position, nil for unmapped columns
+	row     int
+	errs    Errors
+}
+
+// NewReader reads the header row and maps it to the fields of T.
+func NewReader[T any](r io.Reader, opts Options) (*Reader[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, ErrInvalidTarget
+	}
+
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	rd := &Reader[T]{r: cr, opts: opts, row: 1, headers: append([]string(nil), header...)}
+
+	columns := map[string]*column{}
+	order := collectColumns(t, nil, columns, nil)
+
+	seen := map[string]bool{}
+	rd.fields = make([]*column, len(header))
+	for i, h := range header {
+		key := normalize(h)
+		if c, ok := columns[key]; ok && !seen[key] {
+			rd.fields[i] = c
+			seen[key] = true
+		} else if opts.DisallowUnknownColumns {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, h)
+		}
+	}
+	for _, key := range order {
+		if c := columns[key]; c.required && !seen[key] {
+			return nil, fmt.Errorf("%w: %q", ErrMissingColumn, c.name)
+		}
+	}
+
+	return rd, nil
+}
+
+// Header returns the header row as read.
+func (rd *Reader[T]) Header() []string {
+	return rd.headers
+}
+
+// Each streams rows to fn without holding the file in memory. Rows with invalid values
+// are skipped and collected; they are returned as Errors once the input is exhausted.
+// An error from fn stops the import and is returned as is.
+func (rd *Reader[T]) Each(fn func(row int, v *T) error) error {
+	for {
+		var v T
+		ok, err := rd.next(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if rd.opts.MaxErrors > 0 && len(rd.errs) >= rd.opts.MaxErrors {
+				break
+			}
+			continue
+		}
+		if err := fn(rd.row, &v); err != nil {
+			return err
+		}
+	}
+
+	if len(rd.errs) > 0 {
+		return rd.errs
+	}
+
+	return nil
+}
+
+// ReadAll returns every valid row. When some rows fail the valid ones are still
+// returned together with Errors.
+func (rd *Reader[T]) ReadAll() ([]T, error) {
+	var out []T
+	err := rd.Each(func(_ int, v *T) error {
+		out = append(out, *v)
+		return nil
+	})
+
+	return out, err
+}
+
+// Unmarshal decodes all of r into a slice of T.
+func Unmarshal[T any](r io.Reader, opts Options) ([]T, error) {
+	rd, err := NewReader[T](r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rd.ReadAll()
+}
+
+func (rd *Reader[T]) next(v *T) (bool, error) {
+	record, err := rd.r.Read()
+	if err == io.EOF {
+		return false, err
+	}
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		rd.row = perr.Line
+		rd.errs = append(rd.errs, &RowError{Row: perr.Line, Err: perr.Err})
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	rd.row, _ = rd.r.FieldPos(0)
+
+	rv := reflect.ValueOf(v).Elem()
+	failed := false
+	for i, c := range rd.fields {
+		if c == nil {
+			continue
+		}
+		raw := ""
+		if i < len(record) {
+			raw = record[i]
+		}
+		if rd.opts.TrimSpace {
+			raw = strings.TrimSpace(raw)
+		}
+
+		if err := rd.setValue(c, rv, raw); err != nil {
+			rd.errs = append(rd.errs, &RowError{Row: rd.row, Column: rd.headers[i], Field: c.name, Err: err})
+			failed = true
+		}
+	}
+
+	return !failed, nil
+}
+
+func (rd *Reader[T]) setValue(c *column, rv reflect.Value, raw string) error {
+	if raw == "" {
+		if c.required {
+			return errors.New("must be provided")
+		}
+		return nil
+	}
+
+	return setField(rv.FieldByIndex(c.index), raw, rd.opts.TimeLayout)
+}
+
+// collectColumns maps normalized names to fields and returns the names in declaration
+// order.
+func collectColumns(t reflect.Type, index []int, columns map[string]*column, order []string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		idx := append(append([]int(nil), index...), i)
+
+		tag := f.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			order = collectColumns(f.Type, idx, columns, order)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		key := normalize(name)
+		if _, dup := columns[key]; !dup {
+			order = append(order, key)
+		}
+		columns[key] = &column{
+			name:     name,
+			index:    idx,
+			required: opts == "required",
+		}
+	}
+
+	return order
+}
+
+func normalize(h string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-', '\t':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(h)))
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func setField(f reflect.Value, raw, timeLayout string) error {
+	if f.Kind() == reflect.Pointer {
+		v := reflect.New(f.Type().Elem())
+		if err := setField(v.Elem(), raw, timeLayout); err != nil {
+			return err
+		}
+		f.Set(v)
+		return nil
+	}
+
+	switch f.Type() {
+	case timeType:
+		var t time.Time
+		var err error
+		if timeLayout != "" {
+			t, err = time.Parse(timeLayout, raw)
+		} else {
+			t, _, err = timeutil.ParseFlexibleTime(raw)
+		}
+		if err != nil {
+			return errors.New("must be a valid time")
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("must be a duration such as 30s or 5m")
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.ToLower(raw))
+		if err != nil {
+			switch strings.ToLower(raw) {
+			case "yes", "y":
+				b = true
+			case "no", "n":
+				b = false
+			default:
+				return errors.New("must be a boolean value")
+			}
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer value")
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, f.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer value")
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(raw, f.Type().Bits())
+		if err != nil {
+			return errors.New("must be a float value")
+		}
+		f.SetFloat(fl)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+)
+
+var (
+	ErrClientGone = errors.New("client disconnected")
+)
+
+// IsClientGone reports whether the client of r has hung up, so a long running
+// handler can stop work nobody will receive:
+//
+//	for rows.Next() {
+//		if helpers.IsClientGone(r) {
+//			return
+//		}
+//		...
+//	}
+//
+// Server-side deadlines do not count; only cancellation does.
+func IsClientGone(r *http.Request) bool {
+	return errors.Is(r.Context().Err(), context.Canceled)
+}
+
+// IsDisconnectError reports whether err comes from writing to, or waiting on, a
+// client that has gone away: ErrClientGone, context cancellation, a broken pipe or a
+// connection reset. These are usually not worth logging as server errors.
+func IsDisconnectError(err error) bool {
+	return errors.Is(err, ErrClientGone) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// TrackedWriter is a ResponseWriter that remembers the first write error, which
+// net/http otherwise leaves for each caller to check. After a failed write further
+// writes are dropped and return the same error.
+type TrackedWriter struct {
+	http.ResponseWriter
+	err error
+}
+
+func NewTrackedWriter(w http.ResponseWriter) *TrackedWriter {
+	return &TrackedWriter{ResponseWriter: w}
+}
+
+func (tw *TrackedWriter) Write(b []byte) (int, error) {
+	if tw.err != nil {
+		return 0, tw.err
+	}
+
+	n, err := tw.ResponseWriter.Write(b)
+	if err != nil {
+		tw.err = err
+	}
+
+	return n, err
+}
+
+// Err returns the first write error, or nil.
+func (tw *TrackedWriter) Err() error {
+	return tw.err
+}
+
+func (tw *TrackedWriter) Flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok && tw.err == nil {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (tw *TrackedWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// WriteJSONContext is WriteJSON that writes nothing when ctx, normally the request
+// context, is already cancelled. It returns an error wrapping ErrClientGone in that
+// case and when the write fails after the client hung up. A ctx past its deadline
+// still gets the response, as the client may be waiting for it.
+func WriteJSONContext(ctx context.Context, w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%w: %v", ErrClientGone, err)
+	}
+
+	err := WriteJSON(w, status, data, headers)
+	if err != nil && (errors.Is(ctx.Err(), context.Canceled) || IsDisconnectError(err)) {
+		return fmt.Errorf("%w: %v", ErrClientGone, err)
+	}
+
+	return err
+}
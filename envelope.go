@@ -1,9 +1,20 @@
 package helpers
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/filters"
+)
 
 type Envelope map[string]interface{}
 
+// Wrap returns an envelope holding v under key, e.g. Wrap("movie", m).
+func Wrap(key string, v interface{}) Envelope {
+	return Envelope{key: v}
+}
+
 func (e Envelope) Marshal() ([]byte, error) {
 	js, err := json.Marshal(e)
 	if err != nil {
@@ -12,3 +23,88 @@ func (e Envelope) Marshal() ([]byte, error) {
 
 	return js, nil
 }
+
+// MustMarshal is Marshal that panics on error, for values known to be encodable.
+func (e Envelope) MustMarshal() []byte {
+	js, err := e.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return js
+}
+
+// Merge returns a new envelope with the keys of e and other, other winning on
+// conflicts. Neither envelope is modified.
+func (e Envelope) Merge(other Envelope) Envelope {
+	out := make(Envelope, len(e)+len(other))
+	for k, v := range e {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+
+	return out
+}
+
+// WithMetadata sets e["metadata"] to the pagination metadata from
+// filters.CalculateMetadata and returns e, for list responses such as
+// {"movies": [...], "metadata": {...}}.
+func (e Envelope) WithMetadata(m filters.Metadata) Envelope {
+	e["metadata"] = m
+	return e
+}
+
+// Get looks up a dot path such as "movie.genres.0" through nested maps and slices.
+// Structs are traversed through their JSON form, so paths use JSON field names.
+func (e Envelope) Get(path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(e)
+	for _, part := range strings.Split(path, ".") {
+		next, ok := getPathElem(cur, part)
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+
+	return cur, true
+}
+
+func getPathElem(v interface{}, key string) (interface{}, bool) {
+	switch val := v.(type) {
+	case Envelope:
+		child, ok := val[key]
+		return child, ok
+	case map[string]interface{}:
+		child, ok := val[key]
+		return child, ok
+	case map[string]string:
+		child, ok := val[key]
+		return child, ok
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(val) {
+			return nil, false
+		}
+		return val[i], true
+	case nil, string, bool, float64, json.Number:
+		return nil, false
+	}
+
+	// Fall back to the JSON form for structs, typed slices and other maps.
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var generic interface{}
+	if err := json.Unmarshal(js, &generic); err != nil {
+		return nil, false
+	}
+	switch generic.(type) {
+	case map[string]interface{}, []interface{}:
+		return getPathElem(generic, key)
+	}
+
+	return nil, false
+}
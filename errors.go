@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// LogError is called by ServerErrorResponse, and for any response that cannot be
+// written, with the request and the error. Replace it to route 5xx errors to the
+// application's logger or error tracker.
+var LogError = func(r *http.Request, err error) {
+	log.Printf("%s %s: %v", r.Method, r.URL.String(), err)
+}
+
+// ErrorResponse sends {"error": message} with the given status. message can be a
+// string or any JSON encodable value, such as a map of field errors. 429, 502, 503 and
+// 504 responses also get the retry hints of RequestRetryPolicy.
+func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message interface{}) {
+	setRetryHeaders(w, r, status)
+
+	env := Envelope{"error": message}
+	// Only an encoding failure leaves the response unwritten; after a failed write
+	// the headers are already out.
+	if _, err := env.Marshal(); err != nil {
+		LogError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err := WriteJSON(w, status, env, nil); err != nil {
+		LogError(r, err)
+	}
+}
+
+// ServerErrorResponse logs err through LogError and sends a generic 500, so details
+// of the failure are not leaked to the client.
+func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	LogError(r, err)
+
+	message := "the server encountered a problem and could not process your request"
+	ErrorResponse(w, r, http.StatusInternalServerError, message)
+}
+
+// NotFoundResponse sends a 404. It can be used as the router's NotFound handler.
+func NotFoundResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the requested resource could not be found"
+	ErrorResponse(w, r, http.StatusNotFound, message)
+}
+
+// MethodNotAllowedResponse sends a 405. It can be used as the router's
+// MethodNotAllowed handler.
+func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
+	ErrorResponse(w, r, http.StatusMethodNotAllowed, message)
+}
+
+// BadRequestResponse sends a 400 with err's message, e.g. from ReadJSON.
+func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+}
+
+// FailedValidationResponse sends a 422 with the field errors collected by a
+// validator.Validator.
+func FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
+	ErrorResponse(w, r, http.StatusUnprocessableEntity, errors)
+}
+
+// EditConflictResponse sends a 409 for an update that lost an optimistic concurrency
+// race, for example when CheckEditConflict returns ErrEditConflict.
+func EditConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	ErrorResponse(w, r, http.StatusConflict, message)
+}
+
+// RateLimitExceededResponse sends a 429.
+func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
+	message := "rate limit exceeded"
+	ErrorResponse(w, r, http.StatusTooManyRequests, message)
+}
+
+// ServiceUnavailableResponse sends a 503, e.g. while a dependency is down or the
+// server is shutting down.
+func ServiceUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the server is temporarily unable to handle the request, please try again later"
+	ErrorResponse(w, r, http.StatusServiceUnavailable, message)
+}
@@ -0,0 +1,239 @@
+// Package events is an in-process event bus with typed subscriptions. Modules publish
+// domain events, such as a UserSignedUp struct, and others subscribe to them by type,
+// so audit logging, notifications or cache invalidation can react without the
+// publisher importing them.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrClosed = errors.New("events: bus is closed")
+)
+
+type BusOptions struct {
+	// OnError receives handler errors and recovered panics, with the event that caused
+	// them. Errors of synchronous handlers are also returned by Publish.
+	OnError func(event interface{}, err error)
+	// QueueSize is the number of events buffered per asynchronous subscriber. Publish
+	// blocks while a subscriber's queue is full. Defaults to 64.
+	QueueSize int
+}
+
+// Bus dispatches events to the subscribers of their type. The zero value is not
+// usable; create one with NewBus.
+type Bus struct {
+	opts BusOptions
+
+	mu     sync.RWMutex
+	subs   map[reflect.Type][]*subscription
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewBus(opts BusOptions) *Bus {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 64
+	}
+
+	return &Bus{opts: opts, subs: map[reflect.Type][]*subscription{}}
+}
+
+type subscription struct {
+	bus    *Bus
+	handle func(ctx context.Context, event interface{}) error
+
+	// queue is nil for synchronous subscribers.
+	mu     sync.Mutex
+	queue  chan queued
+	closed bool
+	// closeErr is returned to publishers once closed: ErrClosed when the bus was
+	// closed, nil after unsubscribing.
+	closeErr error
+}
+
+type queued struct {
+	ctx   context.Context
+	event interface{}
+}
+
+// Subscribe registers handler for events of type T, to run synchronously in the
+// goroutine calling Publish, in subscription order. Events match on their exact
+// type, so a *T subscriber does not receive T. The returned function unsubscribes.
+func Subscribe[T any](b *Bus, handler func(ctx context.Context, event T) error) (unsubscribe func()) {
+	return b.subscribe(typeOf[T](), wrap(handler), false)
+}
+
+// SubscribeAsync is Subscribe with handler running in its own goroutine. Events are
+// queued and handled one at a time, in publish order. The context passed to handler
+// is the one given to Publish, which may be done by then; use it for its values.
+func SubscribeAsync[T any](b *Bus, handler func(ctx context.Context, event T) error) (unsubscribe func()) {
+	return b.subscribe(typeOf[T](), wrap(handler), true)
+}
+
+// Publish sends event to the subscribers of type T. Synchronous handlers run before
+// Publish returns; all of them run even when one fails or panics, and the first
+// error is returned. Asynchronous handlers only have the event queued. It returns
+// ErrClosed after Close, including when Close races it and an event could not be
+// queued.
+func Publish[T any](ctx context.Context, b *Bus, event T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := b.subs[typeOf[T]()]
+	b.mu.RUnlock()
+
+	var first error
+	for _, s := range subs {
+		if s.queue == nil {
+			if err := s.run(ctx, event); err != nil && first == nil {
+				first = err
+			}
+			continue
+		}
+		if err := s.enqueue(ctx, event); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func wrap[T any](handler func(ctx context.Context, event T) error) func(ctx context.Context, event interface{}) error {
+	return func(ctx context.Context, event interface{}) error {
+		return handler(ctx, event.(T))
+	}
+}
+
+func (b *Bus) subscribe(t reflect.Type, handle func(ctx context.Context, event interface{}) error, async bool) func() {
+	s := &subscription{bus: b, handle: handle}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if async {
+		s.queue = make(chan queued, b.opts.QueueSize)
+		if b.closed {
+			s.closed, s.closeErr = true, ErrClosed
+			close(s.queue)
+		}
+		b.wg.Add(1)
+		go s.work()
+	}
+	// Copy on write, so Publish can range over a snapshot without the lock.
+	subs := make([]*subscription, len(b.subs[t]), len(b.subs[t])+1)
+	copy(subs, b.subs[t])
+	b.subs[t] = append(subs, s)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(t, s) })
+	}
+}
+
+func (b *Bus) unsubscribe(t reflect.Type, s *subscription) {
+	b.mu.Lock()
+	subs := make([]*subscription, 0, len(b.subs[t]))
+	for _, other := range b.subs[t] {
+		if other != s {
+			subs = append(subs, other)
+		}
+	}
+	b.subs[t] = subs
+	b.mu.Unlock()
+
+	s.close(nil)
+}
+
+// run calls the handler, turning a panic into an error.
+func (s *subscription) run(ctx context.Context, event interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("events: handler panicked: %v", r)
+		}
+		if err != nil && s.bus.opts.OnError != nil {
+			s.bus.opts.OnError(event, err)
+		}
+	}()
+
+	return s.handle(ctx, event)
+}
+
+func (s *subscription) enqueue(ctx context.Context, event interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return s.closeErr
+	}
+	select {
+	case s.queue <- queued{ctx: ctx, event: event}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *subscription) work() {
+	defer s.bus.wg.Done()
+
+	for q := range s.queue {
+		s.run(q.ctx, q.event)
+	}
+}
+
+// close stops the queue of an asynchronous subscriber once the events already in it
+// have been handled. Later events get err.
+func (s *subscription) close(err error) {
+	if s.queue == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed, s.closeErr = true, err
+		close(s.queue)
+	}
+}
+
+// Close stops the bus from accepting events and waits for asynchronous subscribers to
+// handle the events already queued, or for ctx to be done, whichever comes first.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	var all []*subscription
+	for _, subs := range b.subs {
+		all = append(all, subs...)
+	}
+	b.mu.Unlock()
+
+	for _, s := range all {
+		s.close(ErrClosed)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,217 @@
+// Package featureflag evaluates boolean, percentage and targeted feature flags loaded
+// from the environment or a JSON file, and exposes the result for each request through
+// its context, so gradual rollouts need no external service.
+package featureflag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidFlag = errors.New("featureflag: invalid flag definition")
+)
+
+// Subject is who a flag is evaluated for. ID drives percentage rollouts, so the same
+// subject keeps getting the same answer.
+type Subject struct {
+	ID         string
+	Attributes map[string]string
+}
+
+// Flag is a flag definition. It is on for a subject when Enabled is set, or when the
+// subject is targeted, or when it falls within Percentage.
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Percentage (0-100) rolls the flag out to a stable share of subject IDs.
+	Percentage float64 `json:"percentage,omitempty"`
+	// Users are subject IDs the flag is always on for.
+	Users []string `json:"users,omitempty"`
+	// Attributes turn the flag on for subjects with any of the listed values, e.g.
+	// {"plan": ["pro", "enterprise"]}.
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// Evaluate reports whether the flag is on for s.
+func (f Flag) Evaluate(s Subject) bool {
+	if f.Enabled {
+		return true
+	}
+	for _, u := range f.Users {
+		if s.ID != "" && u == s.ID {
+			return true
+		}
+	}
+	for attr, values := range f.Attributes {
+		if v, ok := s.Attributes[attr]; ok {
+			for _, want := range values {
+				if v == want {
+					return true
+				}
+			}
+		}
+	}
+	if f.Percentage > 0 && s.ID != "" {
+		return bucket(f.Name, s.ID) < f.Percentage*100
+	}
+
+	return false
+}
+
+// bucket maps name and id to [0, 10000) so percentages have two decimals of
+// precision, and different flags roll out to different subjects.
+func bucket(name, id string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + id))
+	return float64(h.Sum32() % 10000)
+}
+
+// Flags evaluates feature flags.
+type Flags interface {
+	Enabled(name string, s Subject) bool
+	// Evaluate returns every known flag's value for s.
+	Evaluate(s Subject) map[string]bool
+}
+
+// Set is an in-memory Flags. Unknown flags are off. It is safe for concurrent use and
+// can be replaced wholesale with Replace, e.g. after re-reading a file.
+type Set struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+func NewSet(flags ...Flag) *Set {
+	s := &Set{}
+	s.Replace(flags...)
+	return s
+}
+
+// Replace swaps in a new set of definitions.
+func (s *Set) Replace(flags ...Flag) {
+	m := make(map[string]Flag, len(flags))
+	for _, f := range flags {
+		m[f.Name] = f
+	}
+
+	s.mu.Lock()
+	s.flags = m
+	s.mu.Unlock()
+}
+
+func (s *Set) Enabled(name string, subject Subject) bool {
+	s.mu.RLock()
+	f, ok := s.flags[name]
+	s.mu.RUnlock()
+
+	return ok && f.Evaluate(subject)
+}
+
+func (s *Set) Evaluate(subject Subject) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]bool, len(s.flags))
+	for name, f := range s.flags {
+		out[name] = f.Evaluate(subject)
+	}
+
+	return out
+}
+
+// Names returns the defined flag names, sorted.
+func (s *Set) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FromEnv reads flags from environment variables starting with prefix, e.g. with
+// prefix "FEATURE_":
+//
+//	FEATURE_NEW_CHECKOUT=true        on for everyone
+//	FEATURE_DARK_MODE=25%            on for 25% of subjects
+//	FEATURE_BETA=users:alice,bob     on for the listed subject IDs
+//
+// Flag names are the rest of the variable name in lower case: new_checkout.
+func FromEnv(prefix string) (*Set, error) {
+	return fromEnviron(prefix, os.Environ())
+}
+
+func fromEnviron(prefix string, environ []string) (*Set, error) {
+	var flags []Flag
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) || key == prefix {
+			continue
+		}
+
+		f, err := parseEnvFlag(strings.ToLower(strings.TrimPrefix(key, prefix)), strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFlag, key, err)
+		}
+		flags = append(flags, f)
+	}
+
+	return NewSet(flags...), nil
+}
+
+func parseEnvFlag(name, value string) (Flag, error) {
+	f := Flag{Name: name}
+	switch {
+	case strings.HasSuffix(value, "%"):
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || pct < 0 || pct > 100 {
+			return f, errors.New("percentage must be between 0% and 100%")
+		}
+		f.Percentage = pct
+	case strings.HasPrefix(value, "users:"):
+		for _, u := range strings.Split(strings.TrimPrefix(value, "users:"), ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				f.Users = append(f.Users, u)
+			}
+		}
+	default:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, errors.New("must be a boolean, a percentage or users:id,...")
+		}
+		f.Enabled = b
+	}
+
+	return f, nil
+}
+
+// FromJSONFile reads a JSON array of Flag definitions.
+func FromJSONFile(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []Flag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFlag, path, err)
+	}
+	for _, f := range flags {
+		if f.Name == "" || f.Percentage < 0 || f.Percentage > 100 {
+			return nil, fmt.Errorf("%w: %s: %q", ErrInvalidFlag, path, f.Name)
+		}
+	}
+
+	return NewSet(flags...), nil
+}
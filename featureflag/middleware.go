@@ -0,0 +1,65 @@
+package featureflag
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type contextKey struct{}
+
+type MiddlewareOptions struct {
+	// Subject identifies who the request is for, usually from the authenticated user.
+	// Defaults to an anonymous subject, for which only fully enabled flags are on.
+	Subject func(r *http.Request) Subject
+	// Header, if set, names a response header listing the enabled flags, e.g.
+	// "X-Features". Only use it when flag names are not sensitive.
+	Header string
+}
+
+// Middleware evaluates every flag once per request and stores the result in the
+// request context for Enabled and FromContext.
+func Middleware(flags Flags, opts MiddlewareOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var subject Subject
+			if opts.Subject != nil {
+				subject = opts.Subject(r)
+			}
+			evaluated := flags.Evaluate(subject)
+
+			if opts.Header != "" {
+				var on []string
+				for name, enabled := range evaluated {
+					if enabled {
+						on = append(on, name)
+					}
+				}
+				sort.Strings(on)
+				if len(on) > 0 {
+					w.Header().Set(opts.Header, strings.Join(on, ","))
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithFlags(r.Context(), evaluated)))
+		})
+	}
+}
+
+// WithFlags returns a copy of ctx carrying evaluated flags.
+func WithFlags(ctx context.Context, evaluated map[string]bool) context.Context {
+	return context.WithValue(ctx, contextKey{}, evaluated)
+}
+
+// FromContext returns the flags evaluated by Middleware, or nil.
+func FromContext(ctx context.Context) map[string]bool {
+	evaluated, _ := ctx.Value(contextKey{}).(map[string]bool)
+	return evaluated
+}
+
+// Enabled reports whether the named flag was on for this request. Unknown flags, and
+// requests that did not pass through Middleware, are off.
+func Enabled(ctx context.Context, name string) bool {
+	return FromContext(ctx)[name]
+}
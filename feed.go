@@ -0,0 +1,283 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"time"
+)
+
+type FeedFormat string
+
+const (
+	FeedRSS  FeedFormat = "rss"
+	FeedAtom FeedFormat = "atom"
+)
+
+type FeedOptions struct {
+	// Format defaults to FeedRSS.
+	Format FeedFormat
+	Title  string
+	// Link is the page the feed belongs to; SelfURL is the feed's own URL, which Atom
+	// requires as its id when ID is empty.
+	Link        string
+	SelfURL     string
+	ID          string
+	Description string
+	Author      string
+	Language    string
+	// Updated defaults to the newest item's time.
+	Updated time.Time
+}
+
+// FeedItem is an entry of a feed. ID defaults to Link and Updated to Published.
+type FeedItem struct {
+	ID      string
+	Title   string
+	Link    string
+	Summary string
+	// Content is HTML.
+	Content    string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// WriteFeed writes items as an RSS 2.0 or Atom 1.0 document with the matching
+// Content-Type. Text is escaped by encoding/xml, so titles and HTML content can be
+// passed as is.
+func WriteFeed(w http.ResponseWriter, opts FeedOptions, items []FeedItem) error {
+	if opts.Updated.IsZero() {
+		for _, it := range items {
+			if t := it.updated(); t.After(opts.Updated) {
+				opts.Updated = t
+			}
+		}
+	}
+
+	var doc interface{}
+	contentType := "application/rss+xml; charset=utf-8"
+	if opts.Format == FeedAtom {
+		doc = atomFeedOf(opts, items)
+		contentType = "application/atom+xml; charset=utf-8"
+	} else {
+		doc = rssFeedOf(opts, items)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func (it FeedItem) id() string {
+	if it.ID != "" {
+		return it.ID
+	}
+
+	return it.Link
+}
+
+func (it FeedItem) updated() time.Time {
+	if !it.Updated.IsZero() {
+		return it.Updated
+	}
+
+	return it.Published
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr,omitempty"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      *atomLink `xml:"atom:link,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title,omitempty"`
+	Link        string   `xml:"link,omitempty"`
+	GUID        *rssGUID `xml:"guid,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+func rssFeedOf(opts FeedOptions, items []FeedItem) rssFeed {
+	f := rssFeed{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		Channel: rssChannel{
+			Title:       opts.Title,
+			Link:        opts.Link,
+			Description: opts.Description,
+			Language:    opts.Language,
+		},
+	}
+	if !opts.Updated.IsZero() {
+		f.Channel.LastBuildDate = opts.Updated.UTC().Format(time.RFC1123Z)
+	}
+	if opts.SelfURL != "" {
+		f.AtomNS = "http://www.w3.org/2005/Atom"
+		f.Channel.AtomLink = &atomLink{Href: opts.SelfURL, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, it := range items {
+		ri := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Summary,
+			Author:      it.Author,
+			Categories:  it.Categories,
+		}
+		if id := it.id(); id != "" {
+			ri.GUID = &rssGUID{IsPermaLink: id == it.Link, Value: id}
+		}
+		if it.Content != "" {
+			ri.Content = &cdata{Value: it.Content}
+		}
+		if !it.Published.IsZero() {
+			ri.PubDate = it.Published.UTC().Format(time.RFC1123Z)
+		}
+		f.Channel.Items = append(f.Channel.Items, ri)
+	}
+
+	return f
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func atomFeedOf(opts FeedOptions, items []FeedItem) atomFeed {
+	id := opts.ID
+	if id == "" {
+		id = opts.SelfURL
+	}
+	if id == "" {
+		id = opts.Link
+	}
+	updated := opts.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	f := atomFeed{
+		Lang:     opts.Language,
+		ID:       id,
+		Title:    opts.Title,
+		Subtitle: opts.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+	}
+	if opts.Author != "" {
+		f.Author = &atomPerson{Name: opts.Author}
+	}
+	if opts.Link != "" {
+		f.Links = append(f.Links, atomLink{Href: opts.Link, Rel: "alternate", Type: "text/html"})
+	}
+	if opts.SelfURL != "" {
+		f.Links = append(f.Links, atomLink{Href: opts.SelfURL, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, it := range items {
+		e := atomEntry{ID: it.id(), Title: it.Title}
+		// Atom requires updated on every entry.
+		if t := it.updated(); !t.IsZero() {
+			e.Updated = t.UTC().Format(time.RFC3339)
+		} else {
+			e.Updated = f.Updated
+		}
+		if !it.Published.IsZero() {
+			e.Published = it.Published.UTC().Format(time.RFC3339)
+		}
+		if it.Author != "" {
+			e.Author = &atomPerson{Name: it.Author}
+		}
+		if it.Link != "" {
+			e.Links = append(e.Links, atomLink{Href: it.Link, Rel: "alternate"})
+		}
+		if it.Summary != "" {
+			e.Summary = &atomText{Type: "text", Value: it.Summary}
+		}
+		if it.Content != "" {
+			e.Content = &atomText{Type: "html", Value: it.Content}
+		}
+		for _, c := range it.Categories {
+			e.Categories = append(e.Categories, atomCategory{Term: c})
+		}
+		f.Entries = append(f.Entries, e)
+	}
+
+	return f
+}
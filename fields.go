@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/validator"
+)
+
+// ReadFields reads a sparse fieldset such as ?fields=id,name,owner.email. Each field
+// must be in allowed, or be nested under an allowed field ("owner" permits
+// "owner.email"); problems are recorded in v under "fields". It returns nil when the
+// parameter is absent, meaning all fields.
+func ReadFields(r *http.Request, v *validator.Validator, allowed []string) []string {
+	s := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if s == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !fieldAllowed(f, allowed) {
+			v.AddError("fields", "unknown field "+f)
+			continue
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func fieldAllowed(field string, allowed []string) bool {
+	for _, a := range allowed {
+		if field == a || strings.HasPrefix(field, a+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectFields returns data with only the given fields, using data's JSON form so
+// struct tags are honoured. Dotted fields select into nested objects, and arrays are
+// filtered element by element. An empty fields list returns data unchanged.
+func SelectFields(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return selectTree(generic, buildFieldTree(fields)), nil
+}
+
+// Select applies SelectFields to the resource under key, so
+// Envelope{"movies": ms, "metadata": md}.Select("movies", []string{"id", "title"})
+// trims each movie and leaves the metadata and other values untouched.
+func (e Envelope) Select(key string, fields []string) (Envelope, error) {
+	v, ok := e[key]
+	if len(fields) == 0 || !ok {
+		return e, nil
+	}
+
+	sel, err := SelectFields(v, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(Envelope, len(e))
+	for k, v := range e {
+		out[k] = v
+	}
+	out[key] = sel
+
+	return out, nil
+}
+
+// fieldTree maps a field to its selected children; a nil subtree selects the whole
+// value.
+type fieldTree map[string]fieldTree
+
+func buildFieldTree(fields []string) fieldTree {
+	root := fieldTree{}
+	for _, f := range fields {
+		node := root
+		parts := strings.Split(f, ".")
+		for i, p := range parts {
+			child, seen := node[p]
+			if seen && child == nil {
+				// An ancestor is already selected in full.
+				break
+			}
+			if i == len(parts)-1 {
+				node[p] = nil
+				break
+			}
+			if child == nil {
+				child = fieldTree{}
+				node[p] = child
+			}
+			node = child
+		}
+	}
+
+	return root
+}
+
+func selectTree(v interface{}, tree fieldTree) interface{} {
+	if tree == nil {
+		return v
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(tree))
+		for k, sub := range tree {
+			if child, ok := val[k]; ok {
+				out[k] = selectTree(child, sub)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = selectTree(item, tree)
+		}
+		return out
+	}
+
+	return v
+}
@@ -0,0 +1,112 @@
+package filters
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LinkHeader builds an RFC 5988 Link header with first, prev, next and last relations
+// for page based pagination. Each link is the request URL with the page query
+// parameter replaced, so other filters are preserved. It returns "" when there are no
+// records.
+func LinkHeader(r *http.Request, m Metadata) string {
+	if m.LastPage == 0 {
+		return ""
+	}
+
+	page := func(p int) string {
+		return linkURL(r, map[string]string{
+			"page":      strconv.Itoa(p),
+			"page_size": strconv.Itoa(m.PageSize),
+		})
+	}
+
+	links := []string{formatLink(page(m.FirstPage), "first")}
+	if m.CurrentPage > m.FirstPage {
+		prev := m.CurrentPage - 1
+		if prev > m.LastPage {
+			prev = m.LastPage
+		}
+		links = append(links, formatLink(page(prev), "prev"))
+	}
+	if m.CurrentPage < m.LastPage {
+		links = append(links, formatLink(page(m.CurrentPage+1), "next"))
+	}
+	links = append(links, formatLink(page(m.LastPage), "last"))
+
+	return strings.Join(links, ", ")
+}
+
+// CursorLinkHeader builds a Link header for cursor pagination, setting the cursor
+// query parameter. Empty cursors are left out.
+func CursorLinkHeader(r *http.Request, nextCursor, prevCursor string) string {
+	var links []string
+	if prevCursor != "" {
+		links = append(links, formatLink(linkURL(r, map[string]string{"cursor": prevCursor}), "prev"))
+	}
+	if nextCursor != "" {
+		links = append(links, formatLink(linkURL(r, map[string]string{"cursor": nextCursor}), "next"))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// SetLinkHeader sets the Link header from LinkHeader if there is anything to link to.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, m Metadata) {
+	if link := LinkHeader(r, m); link != "" {
+		w.Header().Set("Link", link)
+	}
+}
+
+func linkURL(r *http.Request, params map[string]string) string {
+	u := *r.URL
+	if u.Host == "" && r.Host != "" {
+		u.Host = r.Host
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func formatLink(u, rel string) string {
+	return "<" + u + `>; rel="` + rel + `"`
+}
+
+// ParseLinkHeader parses a Link header into a map of rel to URL, which is handy on
+// the client side and in tests.
+func ParseLinkHeader(header string) map[string]*url.URL {
+	links := map[string]*url.URL{}
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		target = strings.TrimSpace(target)
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		u, err := url.Parse(target[1 : len(target)-1])
+		if err != nil {
+			continue
+		}
+
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "rel") {
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					links[rel] = u
+				}
+			}
+		}
+	}
+
+	return links
+}
@@ -10,13 +10,14 @@ type Metadata struct {
 	TotalRecords int `json:"total_records,omitempty"`
 }
 
-// The calculateMetadata() function calculates the appropriate pagination metadata
+// The CalculateMetadata() function calculates the appropriate pagination metadata
 // values given the total number of records, current page, and page size values. Note
 // that the last page value is calculated using the math.Ceil() function, which rounds
 // up a float to the nearest integer. So, for example, if there were 12 records in total
-// and a page size of 5, the last page value would be math.Ceil(12/5) = 3.
+// and a page size of 5, the last page value would be math.Ceil(12/5) = 3. An empty
+// Metadata is returned when there are no records or the page size is not positive.
 func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
-	if totalRecords == 0 {
+	if totalRecords <= 0 || pageSize <= 0 {
 		return Metadata{}
 	}
 
@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/validator"
+)
+
+// ValidationError holds the problems found by ReadFilters, keyed by query parameter
+// like validator.Validator errors, so it can be passed straight to a failed
+// validation response.
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + ": " + e.Errors[k]
+	}
+
+	return "invalid filters: " + strings.Join(parts, ", ")
+}
+
+// ReadFilters reads the page, page_size and sort query parameters, falling back to
+// defaults for those that are missing, and checks the result with ValidateFilters.
+// defaults.SortSafelist is carried over and must include defaults.Sort:
+//
+//	f, err := filters.ReadFilters(r.URL.Query(), filters.Filters{
+//		Page: 1, PageSize: 20, Sort: "id",
+//		SortSafelist: []string{"id", "title", "-id", "-title"},
+//	})
+//
+// Problems are returned as a *ValidationError.
+func ReadFilters(qs url.Values, defaults Filters) (Filters, error) {
+	v := validator.New()
+	f := defaults
+
+	readInt := func(key string, dst *int) {
+		s := qs.Get(key)
+		if s == "" {
+			return
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			v.AddError(key, "must be an integer value")
+			return
+		}
+		*dst = n
+	}
+	readInt("page", &f.Page)
+	readInt("page_size", &f.PageSize)
+	if s := qs.Get("sort"); s != "" {
+		f.Sort = s
+	}
+
+	ValidateFilters(v, f)
+	if !v.Valid() {
+		return defaults, &ValidationError{Errors: v.Errors}
+	}
+
+	return f, nil
+}
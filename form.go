@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormError reports a form value that could not be stored in its field.
+type FormError struct {
+	Field string
+	// Want describes the expected value, e.g. "an integer".
+	Want string
+}
+
+func (e *FormError) Error() string {
+	return fmt.Sprintf("form field %q must be %s", e.Field, e.Want)
+}
+
+// ReadForm decodes an application/x-www-form-urlencoded or multipart/form-data body
+// into the struct pointed to by dst, the form counterpart of ReadJSON. Fields are
+// matched by their `form:"name"` tag, or their name when untagged; `form:"-"` skips a
+// field and form values without a field are ignored. Strings, bools, numbers,
+// time.Time (RFC 3339 or 2006-01-02), encoding.TextUnmarshaler, pointers and slices
+// of these are supported, and empty values leave non-string fields at their zero
+// value. Bodies are limited to 1MB, like ReadJSON; for larger multipart uploads use
+// ReadFileUpload. Conversion failures are returned as a *FormError.
+func ReadForm(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic("helpers: ReadForm needs a pointer to a struct")
+	}
+
+	maxBytes := int64(1_048_576)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		// With maxMemory at the body limit no part spills to disk.
+		if err := ReadMultipartForm(r, maxBytes); err != nil {
+			return err
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			if strings.Contains(err.Error(), "http: request body too large") {
+				return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+			}
+			return errors.New("body contains a malformed form")
+		}
+	}
+
+	values := r.PostForm
+	if r.MultipartForm != nil {
+		values = url.Values(r.MultipartForm.Value)
+	}
+
+	return decodeForm(values, rv.Elem())
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func decodeForm(values url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("form"); ok {
+			name, _, _ = strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+		}
+
+		vals, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+			slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+			for j, s := range vals {
+				if err := setFormValue(slice.Index(j), s, name); err != nil {
+					return err
+				}
+			}
+			field.Set(slice)
+			continue
+		}
+		if err := setFormValue(field, vals[0], name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setFormValue(v reflect.Value, s, name string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := setFormValue(p.Elem(), s, name); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+
+	// Empty inputs leave non-string fields at their zero value.
+	if strings.TrimSpace(s) == "" && v.Kind() != reflect.String {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Type() == timeType {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+			if tm, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(tm))
+				return nil
+			}
+		}
+		return &FormError{Field: name, Want: "a valid date or time"}
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return &FormError{Field: name, Want: "a valid value"}
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			panic(fmt.Sprintf("helpers: ReadForm does not support field %q of type %s", name, v.Type()))
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		// Checkboxes send "on" when ticked.
+		switch strings.ToLower(s) {
+		case "on", "true", "1", "yes":
+			v.SetBool(true)
+		case "off", "false", "0", "no":
+			v.SetBool(false)
+		default:
+			return &FormError{Field: name, Want: "a boolean"}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return &FormError{Field: name, Want: "an integer"}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return &FormError{Field: name, Want: "a positive integer"}
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return &FormError{Field: name, Want: "a number"}
+		}
+		v.SetFloat(f)
+	default:
+		panic(fmt.Sprintf("helpers: ReadForm does not support field %q of type %s", name, v.Type()))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SanitizeTSQuery turns free text into a Postgres to_tsquery expression that cannot
+// fail to parse: words are reduced to letters and digits and AND-ed together, so
+// `C++ & "rock'n'roll"!` becomes "C & rock & n & roll". With prefix the last word
+// also matches as a prefix ("roll:*"), for search-as-you-type. It returns "" when the
+// input has no words.
+func SanitizeTSQuery(input string, prefix bool) string {
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// FullTextSearch builds Postgres full text search SQL with ? placeholders. Column is
+// a tsvector column or expression, and like Config is written into SQL as is.
+type FullTextSearch struct {
+	// Column is e.g. "search_vector" or "to_tsvector('english', title)".
+	Column string
+	// Config is the text search configuration. Defaults to "english".
+	Config string
+	// Prefix matches the last word as a prefix. It is ignored with WebSearch.
+	Prefix bool
+	// WebSearch passes the input to websearch_to_tsquery (Postgres 11+) instead, so
+	// users can write "quoted phrases", or and -exclusions.
+	WebSearch bool
+}
+
+func (s FullTextSearch) query() string {
+	config := s.Config
+	if config == "" {
+		config = "english"
+	}
+
+	fn := "to_tsquery"
+	if s.WebSearch {
+		fn = "websearch_to_tsquery"
+	}
+
+	return fn + "('" + strings.ReplaceAll(config, "'", "''") + "', ?)"
+}
+
+// arg returns the query argument for input, or "" when it has no words.
+func (s FullTextSearch) arg(input string) string {
+	if !s.WebSearch {
+		return SanitizeTSQuery(input, s.Prefix)
+	}
+	if SanitizeTSQuery(input, false) == "" {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, input))
+}
+
+// Where returns the match condition for input. ok is false when input has no words,
+// in which case the caller should skip the condition or return no results instead of
+// sending an empty query to Postgres.
+func (s FullTextSearch) Where(input string) (cond string, args []interface{}, ok bool) {
+	q := s.arg(input)
+	if q == "" {
+		return "", nil, false
+	}
+
+	return s.Column + " @@ " + s.query(), []interface{}{q}, true
+}
+
+// OrderBy returns a rank ordering for input, best matches first, such as
+// "ts_rank(search_vector, to_tsquery('english', ?)) DESC". ok is false when input
+// has no words.
+func (s FullTextSearch) OrderBy(input string) (expr string, args []interface{}, ok bool) {
+	q := s.arg(input)
+	if q == "" {
+		return "", nil, false
+	}
+
+	return "ts_rank(" + s.Column + ", " + s.query() + ") DESC", []interface{}{q}, true
+}
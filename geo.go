@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"math"
+)
+
+// EarthRadiusMeters is the mean Earth radius used by the distance helpers.
+const EarthRadiusMeters = 6371008.8
+
+// GeoPoint is a WGS 84 coordinate in degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid reports whether p is a real coordinate.
+func (p GeoPoint) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// DistanceTo returns the great-circle distance to q in meters.
+func (p GeoPoint) DistanceTo(q GeoPoint) float64 {
+	lat1, lat2 := radians(p.Lat), radians(q.Lat)
+	dLat, dLng := radians(q.Lat-p.Lat), radians(q.Lng-p.Lng)
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns the corners of a box containing every point within radius
+// meters of p. Near the poles, or when the box would cross the antimeridian, the
+// longitude range is the whole globe.
+func (p GeoPoint) BoundingBox(radius float64) (lo, hi GeoPoint) {
+	dLat := degrees(radius / EarthRadiusMeters)
+	lo.Lat, hi.Lat = math.Max(-90, p.Lat-dLat), math.Min(90, p.Lat+dLat)
+
+	lo.Lng, hi.Lng = -180, 180
+	if lo.Lat > -90 && hi.Lat < 90 {
+		dLng := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadiusMeters)/math.Cos(radians(p.Lat)))))
+		if p.Lng-dLng >= -180 && p.Lng+dLng <= 180 {
+			lo.Lng, hi.Lng = p.Lng-dLng, p.Lng+dLng
+		}
+	}
+
+	return lo, hi
+}
+
+// HaversineSQL returns an expression for the distance in meters between the point in
+// the latCol and lngCol columns and p, with ? placeholders, for selecting or ordering
+// by distance. It works on Postgres and MySQL. Column names are written into SQL as
+// is and must not come from user input.
+func HaversineSQL(latCol, lngCol string, p GeoPoint) (string, []interface{}) {
+	expr := "(? * 2 * ASIN(SQRT(POWER(SIN(RADIANS(" + latCol + " - ?) / 2), 2) + " +
+		"COS(RADIANS(?)) * COS(RADIANS(" + latCol + ")) * POWER(SIN(RADIANS(" + lngCol + " - ?) / 2), 2))))"
+
+	return expr, []interface{}{EarthRadiusMeters, p.Lat, p.Lat, p.Lng}
+}
+
+// WithinRadiusSQL returns a WHERE condition matching rows within radius meters of p.
+// A bounding box comparison comes first so an index on the columns can narrow the
+// rows before the haversine distance is computed:
+//
+//	cond, args := helpers.WithinRadiusSQL("lat", "lng", here, 5000)
+//	query := "SELECT ... FROM shops WHERE " + cond
+func WithinRadiusSQL(latCol, lngCol string, p GeoPoint, radius float64) (string, []interface{}) {
+	lo, hi := p.BoundingBox(radius)
+	cond := latCol + " BETWEEN ? AND ?"
+	args := []interface{}{lo.Lat, hi.Lat}
+	if lo.Lng > -180 || hi.Lng < 180 {
+		cond += " AND " + lngCol + " BETWEEN ? AND ?"
+		args = append(args, lo.Lng, hi.Lng)
+	}
+
+	dist, distArgs := HaversineSQL(latCol, lngCol, p)
+	cond += " AND " + dist + " <= ?"
+	args = append(append(args, distArgs...), radius)
+
+	return cond, args
+}
+
+// STDWithinSQL returns a PostGIS condition matching rows whose geography column is
+// within radius meters of p, which can use a GiST index on the column.
+func STDWithinSQL(geogCol string, p GeoPoint, radius float64) (string, []interface{}) {
+	cond := "ST_DWithin(" + geogCol + ", ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)"
+	return cond, []interface{}{p.Lng, p.Lat, radius}
+}
+
+// STDistanceSQL returns a PostGIS expression for the distance in meters between the
+// geography column and p.
+func STDistanceSQL(geogCol string, p GeoPoint) (string, []interface{}) {
+	expr := "ST_Distance(" + geogCol + ", ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography)"
+	return expr, []interface{}{p.Lng, p.Lat}
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
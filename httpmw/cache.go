@@ -0,0 +1,256 @@
+package httpmw
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/cache"
+)
+
+// CachedResponse is a response stored by ResponseCache.
+type CachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Tags   []string    `json:"tags,omitempty"`
+}
+
+type ResponseCacheOptions struct {
+	// TTL is how long responses are cached. Defaults to one minute.
+	TTL time.Duration
+	// Store holds cached responses. Defaults to an in-memory store.
+	Store cache.Store[CachedResponse]
+	// Vary lists request headers which are part of the cache key, e.g. Accept.
+	Vary []string
+	// KeyFunc overrides how the cache key is built. It receives the key built from the
+	// path, sorted query string and Vary headers and may extend it, e.g. with a user ID.
+	KeyFunc func(r *http.Request, key string) string
+	// Tags returns the invalidation tags for a request. Handlers may also add tags with
+	// TagResponse.
+	Tags func(r *http.Request) []string
+}
+
+// ResponseCache caches whole GET responses and supports tag-based invalidation. The
+// tag index is kept in process, so InvalidateTag only reaches entries cached by the
+// same instance even when Store is shared. Keys are dropped from the index once their
+// entry has expired, at most one TTL late.
+type ResponseCache struct {
+	opts ResponseCacheOptions
+
+	mu        sync.Mutex
+	tags      map[string]map[string]struct{}
+	keys      map[string]taggedKey
+	lastSweep time.Time
+}
+
+// taggedKey is a cached key's tags and expiry, for pruning the tag index.
+type taggedKey struct {
+	tags    []string
+	expires time.Time
+}
+
+func NewResponseCache(opts ResponseCacheOptions) *ResponseCache {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.Store == nil {
+		opts.Store = cache.NewMemoryStore[CachedResponse](cache.Options{CleanupInterval: time.Minute})
+	}
+
+	return &ResponseCache{
+		opts:      opts,
+		tags:      make(map[string]map[string]struct{}),
+		keys:      make(map[string]taggedKey),
+		lastSweep: time.Now(),
+	}
+}
+
+type responseTagsKey struct{}
+
+// TagResponse attaches invalidation tags to the response being cached for r. It is a
+// no-op outside ResponseCache.
+func TagResponse(r *http.Request, tags ...string) {
+	if p, ok := r.Context().Value(responseTagsKey{}).(*[]string); ok {
+		*p = append(*p, tags...)
+	}
+}
+
+func (c *ResponseCache) key(r *http.Request) string {
+	key := requestKey(r, c.opts.Vary)
+	if c.opts.KeyFunc != nil {
+		key = c.opts.KeyFunc(r, key)
+	}
+
+	return key
+}
+
+// requestKey identifies a request by path, sorted query string and the given headers.
+func requestKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(r.URL.Path)
+
+	q := r.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		vals := q[k]
+		sort.Strings(vals)
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(vals, ","))
+	}
+
+	for _, h := range vary {
+		b.WriteString("|")
+		b.WriteString(h)
+		b.WriteString("=")
+		b.WriteString(r.Header.Get(h))
+	}
+
+	return b.String()
+}
+
+// Middleware serves cached GET responses and caches fresh 200 responses. Requests
+// with Cache-Control: no-cache bypass the lookup and responses setting cookies or
+// Cache-Control: no-store/private are never cached.
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := c.key(r)
+		if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			if cached, ok, err := c.opts.Store.Get(r.Context(), key); err == nil && ok {
+				for name, values := range cached.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(cached.Status)
+				w.Write(cached.Body)
+				return
+			}
+		}
+
+		var tags []string
+		if c.opts.Tags != nil {
+			tags = append(tags, c.opts.Tags(r)...)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), responseTagsKey{}, &tags))
+
+		w.Header().Set("X-Cache", "MISS")
+		rw := wrapResponseWriter(w)
+		rw.body = &bytes.Buffer{}
+		next.ServeHTTP(rw, r)
+
+		if rw.status != http.StatusOK || !cacheable(w.Header()) {
+			return
+		}
+
+		header := w.Header().Clone()
+		header.Del("X-Cache")
+		resp := CachedResponse{Status: rw.status, Header: header, Body: rw.body.Bytes(), Tags: tags}
+		if err := c.opts.Store.Set(r.Context(), key, resp, c.opts.TTL); err != nil {
+			return
+		}
+
+		c.index(key, tags)
+	})
+}
+
+// index records key under its tags, replacing any earlier tags, and prunes expired keys
+// once per TTL.
+func (c *ResponseCache) index(key string, tags []string) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unindex(key)
+	if len(tags) > 0 {
+		c.keys[key] = taggedKey{tags: tags, expires: now.Add(c.opts.TTL)}
+		for _, tag := range tags {
+			if c.tags[tag] == nil {
+				c.tags[tag] = make(map[string]struct{})
+			}
+			c.tags[tag][key] = struct{}{}
+		}
+	}
+
+	if now.Sub(c.lastSweep) >= c.opts.TTL {
+		c.lastSweep = now
+		for k, tk := range c.keys {
+			if !now.Before(tk.expires) {
+				c.unindex(k)
+			}
+		}
+	}
+}
+
+// unindex removes key from the tag index. The caller must hold the mutex.
+func (c *ResponseCache) unindex(key string) {
+	tk, ok := c.keys[key]
+	if !ok {
+		return
+	}
+	delete(c.keys, key)
+	for _, tag := range tk.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+func cacheable(h http.Header) bool {
+	if h.Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// InvalidateTag drops every cached response tagged with tag.
+func (c *ResponseCache) InvalidateTag(ctx context.Context, tag string) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.tags[tag]))
+	for key := range c.tags[tag] {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		c.unindex(key)
+	}
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		if err := c.opts.Store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Invalidate drops the cached response for r's key.
+func (c *ResponseCache) Invalidate(ctx context.Context, r *http.Request) error {
+	key := c.key(r)
+	c.mu.Lock()
+	c.unindex(key)
+	c.mu.Unlock()
+
+	return c.opts.Store.Delete(ctx, key)
+}
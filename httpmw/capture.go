@@ -0,0 +1,303 @@
+package httpmw
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactFields are the body fields CaptureOptions redacts by default.
+var DefaultRedactFields = []string{"password", "password_confirmation", "token", "access_token", "refresh_token", "secret", "client_secret", "api_key"}
+
+const redacted = "[REDACTED]"
+
+type CaptureOptions struct {
+	// MaxBodySize caps how much of each body is kept. Defaults to 4KB.
+	MaxBodySize int
+	// RedactFields are JSON, form and query parameter names, matched
+	// case-insensitively at any depth, whose values are replaced. Defaults to
+	// DefaultRedactFields.
+	RedactFields []string
+	// RedactHeaders are header names whose values are replaced. Authorization, Cookie
+	// and Set-Cookie are always redacted.
+	RedactHeaders []string
+	// Filter selects which requests are captured. Defaults to all of them.
+	Filter func(r *http.Request) bool
+	// Logger receives each exchange at LevelDebug under the message "http exchange".
+	Logger Logger
+	// Buffer keeps the most recent exchanges for CaptureBuffer.Handler.
+	Buffer *CaptureBuffer
+}
+
+// Exchange is a captured request and response.
+type Exchange struct {
+	Time              time.Time   `json:"time"`
+	Method            string      `json:"method"`
+	URL               string      `json:"url"`
+	Status            int         `json:"status"`
+	Duration          string      `json:"duration"`
+	RequestHeaders    http.Header `json:"request_headers"`
+	RequestBody       string      `json:"request_body,omitempty"`
+	RequestTruncated  bool        `json:"request_truncated,omitempty"`
+	ResponseHeaders   http.Header `json:"response_headers"`
+	ResponseBody      string      `json:"response_body,omitempty"`
+	ResponseTruncated bool        `json:"response_truncated,omitempty"`
+}
+
+// Capture is an opt-in debugging middleware that copies request and response bodies,
+// size-capped and with secrets redacted, to a Logger and/or a CaptureBuffer. It keeps
+// bodies in memory, so enable it selectively.
+func Capture(opts CaptureOptions) func(http.Handler) http.Handler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 4 << 10
+	}
+	if opts.RedactFields == nil {
+		opts.RedactFields = DefaultRedactFields
+	}
+	fields := make(map[string]bool, len(opts.RedactFields))
+	for _, f := range opts.RedactFields {
+		fields[strings.ToLower(f)] = true
+	}
+	headers := append([]string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}, opts.RedactHeaders...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Filter != nil && !opts.Filter(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			reqBody := &cappedBuffer{max: opts.MaxBodySize}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeReadCloser{ReadCloser: r.Body, w: reqBody}
+			}
+
+			rw := wrapResponseWriter(w)
+			rw.body = &bytes.Buffer{}
+			rw.maxBody = opts.MaxBodySize + 1
+
+			next.ServeHTTP(rw, r)
+
+			respBody := rw.body.Bytes()
+			respTruncated := len(respBody) > opts.MaxBodySize
+			if respTruncated {
+				respBody = respBody[:opts.MaxBodySize]
+			}
+
+			ex := Exchange{
+				Time:              start,
+				Method:            r.Method,
+				URL:               redactURL(r.URL, fields),
+				Status:            rw.status,
+				Duration:          time.Since(start).String(),
+				RequestHeaders:    redactHeaders(r.Header, headers),
+				RequestBody:       redactBody(reqBody.buf.Bytes(), r.Header.Get("Content-Type"), fields, reqBody.truncated),
+				RequestTruncated:  reqBody.truncated,
+				ResponseHeaders:   redactHeaders(rw.Header(), headers),
+				ResponseBody:      redactBody(respBody, rw.Header().Get("Content-Type"), fields, respTruncated),
+				ResponseTruncated: respTruncated,
+			}
+
+			if opts.Buffer != nil {
+				opts.Buffer.add(ex)
+			}
+			if opts.Logger != nil {
+				opts.Logger.Log(r.Context(), LevelDebug, "http exchange", map[string]interface{}{"exchange": ex})
+			}
+		})
+	}
+}
+
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		if room > 0 {
+			c.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+
+	return c.buf.Write(p)
+}
+
+type teeReadCloser struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.w.Write(p[:n])
+	}
+	return n, err
+}
+
+func redactHeaders(h http.Header, names []string) http.Header {
+	out := h.Clone()
+	for _, name := range names {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, redacted)
+		}
+	}
+
+	return out
+}
+
+// redactURL masks sensitive query parameters such as ?access_token=, keeping the order
+// of the others.
+func redactURL(u *url.URL, fields map[string]bool) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	parts := strings.Split(u.RawQuery, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err != nil || fields[strings.ToLower(name)] {
+			parts[i] = key + "=" + redacted
+		}
+	}
+	c := *u
+	c.RawQuery = strings.Join(parts, "&")
+
+	return c.String()
+}
+
+// redactBody masks sensitive fields in JSON and form bodies. Truncated JSON can't be
+// parsed, so it is withheld entirely rather than risk leaking a secret.
+func redactBody(body []byte, contentType string, fields map[string]bool, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v interface{}
+		if truncated || json.Unmarshal(body, &v) != nil {
+			return "[unparsable JSON body withheld]"
+		}
+		js, _ := json.Marshal(redactValue(v, fields))
+		return string(js)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		q, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[unparsable form body withheld]"
+		}
+		for k := range q {
+			if fields[strings.ToLower(k)] {
+				q[k] = []string{redacted}
+			}
+		}
+		return q.Encode()
+	case strings.HasPrefix(contentType, "text/"):
+		return string(body)
+	}
+
+	return "[" + contentType + " body omitted]"
+}
+
+func redactValue(v interface{}, fields map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if fields[strings.ToLower(k)] {
+				val[k] = redacted
+			} else {
+				val[k] = redactValue(child, fields)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child, fields)
+		}
+	}
+
+	return v
+}
+
+// CaptureBuffer is a fixed-size ring of recent exchanges.
+type CaptureBuffer struct {
+	mu      sync.Mutex
+	entries []Exchange
+	next    int
+	full    bool
+}
+
+// NewCaptureBuffer keeps the last size exchanges.
+func NewCaptureBuffer(size int) *CaptureBuffer {
+	if size < 1 {
+		size = 1
+	}
+
+	return &CaptureBuffer{entries: make([]Exchange, size)}
+}
+
+func (b *CaptureBuffer) add(ex Exchange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = ex
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Entries returns the captured exchanges, newest first.
+func (b *CaptureBuffer) Entries() []Exchange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+	out := make([]Exchange, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, b.entries[(b.next-i+len(b.entries))%len(b.entries)])
+	}
+
+	return out
+}
+
+// Handler serves the entries as JSON to requests allowed by guard and responds 404 to
+// everyone else, so the endpoint is not discoverable. guard must not be nil.
+func (b *CaptureBuffer) Handler(guard func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !guard(r) {
+			http.NotFound(w, r)
+			return
+		}
+
+		js, err := json.Marshal(map[string]interface{}{"exchanges": b.Entries()})
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(js)
+	})
+}
+
+// RequireToken is a guard for CaptureBuffer.Handler accepting requests whose
+// X-Debug-Token header equals token. An empty token denies everything.
+func RequireToken(token string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Debug-Token")), []byte(token)) == 1
+	}
+}
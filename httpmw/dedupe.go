@@ -0,0 +1,99 @@
+package httpmw
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/hasahmad/go-helpers/cache"
+)
+
+type DedupeOptions struct {
+	// User identifies the caller so different users never share a response. Defaults
+	// to the Authorization and Cookie headers.
+	User func(r *http.Request) string
+	// Vary lists further request headers that are part of the key, e.g. Accept.
+	Vary []string
+}
+
+// Dedupe coalesces concurrent identical GET requests, with the same path, query,
+// Vary headers and user, into a single handler execution and sends its response to
+// every waiting client. Requests arriving after the handler returns run it again;
+// combine with ResponseCache to serve later requests too.
+//
+// The handler runs with the first request, so if that client goes away and the
+// handler honours its context, the waiters get the cancelled response.
+func Dedupe(opts DedupeOptions) func(http.Handler) http.Handler {
+	if opts.User == nil {
+		opts.User = func(r *http.Request) string {
+			return r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie")
+		}
+	}
+
+	var group cache.Group[string, *dedupeRecorder]
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := requestKey(r, opts.Vary) + "|" + opts.User(r)
+
+			// fn only runs for the request that executes the handler, which re-panics
+			// so Recoverer still sees the panic; the waiters get a 500.
+			var panicked interface{}
+			rec, err, _ := group.Do(key, func() (*dedupeRecorder, error) {
+				rec := &dedupeRecorder{header: http.Header{}, status: http.StatusOK}
+				defer func() {
+					if panicked = recover(); panicked != nil {
+						panic(panicked)
+					}
+				}()
+				next.ServeHTTP(rec, r)
+				return rec, nil
+			})
+			if panicked != nil {
+				panic(panicked)
+			}
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			rec.writeTo(w)
+		})
+	}
+}
+
+func (rec *dedupeRecorder) writeTo(w http.ResponseWriter) {
+	for name, values := range rec.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
+
+// dedupeRecorder buffers the shared response.
+type dedupeRecorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (r *dedupeRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *dedupeRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *dedupeRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
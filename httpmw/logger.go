@@ -0,0 +1,83 @@
+package httpmw
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+
+	return "ERROR"
+}
+
+// Logger is the structured logger the middleware in this package writes to. Adapt
+// your logger of choice with LoggerFunc.
+type Logger interface {
+	Log(ctx context.Context, level Level, msg string, fields map[string]interface{})
+}
+
+// LoggerFunc adapts a function to Logger.
+type LoggerFunc func(ctx context.Context, level Level, msg string, fields map[string]interface{})
+
+func (f LoggerFunc) Log(ctx context.Context, level Level, msg string, fields map[string]interface{}) {
+	f(ctx, level, msg, fields)
+}
+
+type jsonLogger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	minLevel Level
+}
+
+// NewJSONLogger returns a Logger writing one JSON object per line to out, with
+// "time", "level" and "message" keys alongside the fields. Entries below minLevel are
+// dropped.
+func NewJSONLogger(out io.Writer, minLevel Level) Logger {
+	return &jsonLogger{out: out, minLevel: minLevel}
+}
+
+func (l *jsonLogger) Log(_ context.Context, level Level, msg string, fields map[string]interface{}) {
+	if level < l.minLevel {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339)
+	entry["level"] = level.String()
+	entry["message"] = msg
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]string{"level": LevelError.String(), "message": "unable to encode log entry: " + err.Error()})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(append(line, '\n'))
+}
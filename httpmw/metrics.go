@@ -0,0 +1,296 @@
+package httpmw
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	// DefaultDurationBuckets are in seconds.
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are in bytes, from 100B to 100MB.
+	DefaultSizeBuckets = []float64{100, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8}
+)
+
+type MetricsOptions struct {
+	// DurationBuckets are the upper bounds of the request and JSON decode duration
+	// histograms. Defaults to DefaultDurationBuckets.
+	DurationBuckets []float64
+	// SizeBuckets are the upper bounds of the body size histograms. Defaults to
+	// DefaultSizeBuckets.
+	SizeBuckets []float64
+	// Route labels a request. Defaults to the chi route pattern, or "unmatched", so
+	// paths with ids do not create a series each.
+	Route func(r *http.Request) string
+}
+
+// Metrics collects per-route request counts and histograms of request duration,
+// request and response body size and JSON decode time, and serves them in the
+// Prometheus text format:
+//
+//	m := httpmw.NewMetrics(httpmw.MetricsOptions{})
+//	r.Use(m.Middleware)
+//	r.Get("/metrics", m.Handler().ServeHTTP)
+//
+// Add it with chi's Use so the route pattern is known when the request completes.
+type Metrics struct {
+	opts MetricsOptions
+
+	mu     sync.Mutex
+	routes map[routeKey]*routeMetrics
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type routeMetrics struct {
+	status       map[int]uint64
+	duration     *histogram
+	requestSize  *histogram
+	responseSize *histogram
+	jsonDecode   *histogram
+}
+
+func NewMetrics(opts MetricsOptions) *Metrics {
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = DefaultDurationBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = DefaultSizeBuckets
+	}
+	if opts.Route == nil {
+		opts.Route = chiRoute
+	}
+
+	return &Metrics{opts: opts, routes: map[routeKey]*routeMetrics{}}
+}
+
+func chiRoute(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" {
+			return p
+		}
+	}
+
+	return "unmatched"
+}
+
+type metricsKey struct{}
+
+// requestObservation gathers what a request reports while it runs.
+type requestObservation struct {
+	mu         sync.Mutex
+	jsonDecode []time.Duration
+}
+
+// Middleware records metrics for every request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		obs := &requestObservation{}
+		body := &countingReadCloser{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		rw := wrapResponseWriter(w)
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), metricsKey{}, obs)))
+
+		// Handlers may not read the whole body, so prefer the declared length.
+		reqSize := body.n
+		if r.ContentLength > reqSize {
+			reqSize = r.ContentLength
+		}
+		m.observe(r, rw.status, time.Since(start), reqSize, int64(rw.bytes), obs)
+	})
+}
+
+// TimeJSONDecode runs decode, typically a helpers.ReadJSON call, and records how
+// long it took under the request's route. Outside Metrics.Middleware it only runs
+// decode.
+//
+//	err := httpmw.TimeJSONDecode(r, func() error { return helpers.ReadJSON(w, r, &input) })
+func TimeJSONDecode(r *http.Request, decode func() error) error {
+	obs, ok := r.Context().Value(metricsKey{}).(*requestObservation)
+	if !ok {
+		return decode()
+	}
+
+	start := time.Now()
+	err := decode()
+	obs.mu.Lock()
+	obs.jsonDecode = append(obs.jsonDecode, time.Since(start))
+	obs.mu.Unlock()
+
+	return err
+}
+
+func (m *Metrics) observe(r *http.Request, status int, d time.Duration, reqSize, respSize int64, obs *requestObservation) {
+	key := routeKey{method: methodLabel(r.Method), route: m.opts.Route(r)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm, ok := m.routes[key]
+	if !ok {
+		rm = &routeMetrics{
+			status:       map[int]uint64{},
+			duration:     newHistogram(m.opts.DurationBuckets),
+			requestSize:  newHistogram(m.opts.SizeBuckets),
+			responseSize: newHistogram(m.opts.SizeBuckets),
+			jsonDecode:   newHistogram(m.opts.DurationBuckets),
+		}
+		m.routes[key] = rm
+	}
+
+	rm.status[status]++
+	rm.duration.observe(d.Seconds())
+	rm.requestSize.observe(float64(reqSize))
+	rm.responseSize.observe(float64(respSize))
+	obs.mu.Lock()
+	for _, jd := range obs.jsonDecode {
+		rm.jsonDecode.observe(jd.Seconds())
+	}
+	obs.mu.Unlock()
+}
+
+// Handler serves the collected metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]routeKey, 0, len(m.routes))
+	for k := range m.routes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Requests by method, route and status.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, k := range keys {
+		statuses := make([]int, 0, len(m.routes[k].status))
+		for s := range m.routes[k].status {
+			statuses = append(statuses, s)
+		}
+		sort.Ints(statuses)
+		for _, s := range statuses {
+			fmt.Fprintf(&b, "http_requests_total{%s,status=\"%d\"} %d\n", k.labels(), s, m.routes[k].status[s])
+		}
+	}
+
+	histograms := []struct {
+		name, help string
+		get        func(*routeMetrics) *histogram
+	}{
+		{"http_request_duration_seconds", "Request duration.", func(rm *routeMetrics) *histogram { return rm.duration }},
+		{"http_request_size_bytes", "Request body size.", func(rm *routeMetrics) *histogram { return rm.requestSize }},
+		{"http_response_size_bytes", "Response body size.", func(rm *routeMetrics) *histogram { return rm.responseSize }},
+		{"http_json_decode_duration_seconds", "JSON request body decode duration.", func(rm *routeMetrics) *histogram { return rm.jsonDecode }},
+	}
+	for _, h := range histograms {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+		for _, k := range keys {
+			hist := h.get(m.routes[k])
+			if hist.count == 0 {
+				continue
+			}
+			hist.write(&b, h.name, k.labels())
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (k routeKey) labels() string {
+	return `method="` + labelValue(k.method) + `",route="` + labelValue(k.route) + `"`
+}
+
+// methodLabel maps methods outside the standard set to "OTHER", so clients cannot
+// create a series per made-up method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+
+	return "OTHER"
+}
+
+// labelValueEscaper escapes a label value as the Prometheus text format requires.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(b *strings.Builder, name, labels string) {
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// countingReadCloser counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+package httpmw
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultTrustedProxies are the loopback and private networks, where load balancers
+// and reverse proxies usually sit.
+var DefaultTrustedProxies = []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+type RealIPOptions struct {
+	// TrustedProxies are the CIDRs whose X-Forwarded-For and X-Real-IP headers are
+	// believed. Defaults to DefaultTrustedProxies.
+	TrustedProxies []string
+}
+
+type clientIPKey struct{}
+
+// RealIP resolves the client address behind trusted proxies and stores it for
+// ClientIP. X-Forwarded-For is read from the right, skipping trusted proxies, so a
+// client cannot spoof its address by sending the header itself. It panics on an
+// invalid CIDR.
+func RealIP(opts RealIPOptions) func(http.Handler) http.Handler {
+	if opts.TrustedProxies == nil {
+		opts.TrustedProxies = DefaultTrustedProxies
+	}
+	trusted := make([]*net.IPNet, len(opts.TrustedProxies))
+	for i, cidr := range opts.TrustedProxies {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("httpmw: invalid trusted proxy " + cidr)
+		}
+		trusted[i] = n
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// ClientIP returns the address resolved by RealIP, or the host of r.RemoteAddr
+// without it.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolveClientIP(r *http.Request, trusted []*net.IPNet) string {
+	remote := remoteHost(r)
+	if ip := net.ParseIP(remote); ip == nil || !isTrusted(ip, trusted) {
+		return remote
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			ip := net.ParseIP(hop)
+			if ip == nil {
+				break
+			}
+			if i == 0 || !isTrusted(ip, trusted) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	return remote
+}
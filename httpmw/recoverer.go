@@ -0,0 +1,64 @@
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	helpers "github.com/hasahmad/go-helpers"
+)
+
+type RecovererOptions struct {
+	// Logger receives each panic at LevelError with its stack.
+	Logger Logger
+	// ErrorResponse writes the response after a panic. Defaults to
+	// helpers.ServerErrorResponse, which also reports err through helpers.LogError.
+	ErrorResponse func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Recoverer turns a panic in a later handler into a logged error and a JSON 500,
+// unless the response had already started, in which case the connection is closed.
+// http.ErrAbortHandler is re-raised so the server still aborts the response quietly.
+func Recoverer(opts RecovererOptions) func(http.Handler) http.Handler {
+	if opts.ErrorResponse == nil {
+		opts.ErrorResponse = helpers.ServerErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := wrapResponseWriter(w)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+				if opts.Logger != nil {
+					opts.Logger.Log(r.Context(), LevelError, "panic recovered", map[string]interface{}{
+						"error":      err,
+						"method":     r.Method,
+						"path":       r.URL.Path,
+						"request_id": GetRequestID(r.Context()),
+						"stack":      string(debug.Stack()),
+					})
+				}
+
+				if rw.wroteHeader {
+					// Part of the response is out; a 500 now would be appended to it.
+					panic(http.ErrAbortHandler)
+				}
+				rw.Header().Set("Connection", "close")
+				opts.ErrorResponse(rw, r, err)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
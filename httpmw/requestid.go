@@ -0,0 +1,63 @@
+package httpmw
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type RequestIDOptions struct {
+	// Header carries the ID on the request and response. Defaults to "X-Request-Id".
+	Header string
+	// Generate returns a new ID. Defaults to a random UUID.
+	Generate func() string
+	// IgnoreIncoming always generates an ID instead of keeping one sent by the client
+	// or a proxy.
+	IgnoreIncoming bool
+}
+
+type requestIDKey struct{}
+
+// RequestID gives every request an ID, stores it in the context for GetRequestID and
+// echoes it in the response header. An incoming ID of up to 128 printable ASCII
+// characters is kept, so IDs set by a load balancer follow the request through.
+func RequestID(opts RequestIDOptions) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		opts.Header = "X-Request-Id"
+	}
+	if opts.Generate == nil {
+		opts.Generate = uuid.NewString
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(opts.Header)
+			if opts.IgnoreIncoming || !validRequestID(id) {
+				id = opts.Generate()
+			}
+
+			w.Header().Set(opts.Header, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// GetRequestID returns the ID set by RequestID, or "".
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
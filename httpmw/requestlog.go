@@ -0,0 +1,58 @@
+package httpmw
+
+import (
+	"net/http"
+	"os"
+	"time"
+)
+
+type RequestLoggerOptions struct {
+	// Logger defaults to JSON lines on stdout at LevelInfo and above.
+	Logger Logger
+	// Skip leaves out matching requests, such as health checks.
+	Skip func(r *http.Request) bool
+}
+
+// RequestLogger logs every request once it completes with its method, path, status,
+// duration, response size, client IP (see RealIP) and request ID (see RequestID).
+// 5xx responses are logged at LevelError, 4xx at LevelWarn and the rest at LevelInfo.
+// Add it after RequestID and RealIP and before Recoverer, so panics are logged as 500s.
+func RequestLogger(opts RequestLoggerOptions) func(http.Handler) http.Handler {
+	if opts.Logger == nil {
+		opts.Logger = NewJSONLogger(os.Stdout, LevelInfo)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := wrapResponseWriter(w)
+			defer func() {
+				level := LevelInfo
+				switch {
+				case rw.status >= 500:
+					level = LevelError
+				case rw.status >= 400:
+					level = LevelWarn
+				}
+
+				opts.Logger.Log(r.Context(), level, "request completed", map[string]interface{}{
+					"method":      r.Method,
+					"path":        r.URL.Path,
+					"status":      rw.status,
+					"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+					"bytes":       rw.bytes,
+					"remote_ip":   ClientIP(r),
+					"request_id":  GetRequestID(r.Context()),
+					"user_agent":  r.UserAgent(),
+				})
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
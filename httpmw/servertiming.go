@@ -0,0 +1,126 @@
+package httpmw
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ServerTimingOptions struct {
+	// Total names the metric for the whole handler duration. Defaults to "total".
+	Total string
+	// Allow decides whether the header is sent, e.g. only to internal clients, since
+	// timings reveal details about the backend. Defaults to always.
+	Allow func(r *http.Request) bool
+}
+
+// ServerTiming emits a Server-Timing header with the handler duration and the
+// segments recorded through StartTiming and AddTiming, so browser dev tools and APM
+// agents can break the request down:
+//
+//	stop := httpmw.StartTiming(r.Context(), "db")
+//	movies, err := loadMovies(ctx)
+//	stop()
+//
+// The header is set when the response headers are written, so segments recorded
+// after that are not reported.
+func ServerTiming(opts ServerTimingOptions) func(http.Handler) http.Handler {
+	if opts.Total == "" {
+		opts.Total = "total"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Allow != nil && !opts.Allow(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			t := &timings{start: time.Now()}
+			rw := wrapResponseWriter(w)
+			rw.beforeWriteHeader = func(int) { t.setHeader(w.Header(), opts.Total) }
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), timingsKey{}, t)))
+			if !rw.wroteHeader {
+				t.setHeader(w.Header(), opts.Total)
+			}
+		})
+	}
+}
+
+type timingsKey struct{}
+
+type timing struct {
+	name string
+	desc string
+	dur  time.Duration
+}
+
+type timings struct {
+	start time.Time
+
+	mu      sync.Mutex
+	entries []timing
+}
+
+func (t *timings) add(name, desc string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.entries {
+		if t.entries[i].name == name {
+			t.entries[i].dur += d
+			return
+		}
+	}
+	t.entries = append(t.entries, timing{name: name, desc: desc, dur: d})
+}
+
+// StartTiming starts timing the named segment and returns the function that stops
+// it. Repeated segments with the same name are summed. It is a no-op outside
+// ServerTiming.
+func StartTiming(ctx context.Context, name string) func() {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return func() {}
+	}
+
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.add(name, "", time.Since(start)) })
+	}
+}
+
+// AddTiming records a segment measured elsewhere, with an optional description.
+func AddTiming(ctx context.Context, name, desc string, d time.Duration) {
+	if t, ok := ctx.Value(timingsKey{}).(*timings); ok {
+		t.add(name, desc, d)
+	}
+}
+
+// setHeader adds the Server-Timing header with the segments so far and the total.
+func (t *timings) setHeader(h http.Header, total string) {
+	t.mu.Lock()
+	entries := append([]timing(nil), t.entries...)
+	t.mu.Unlock()
+	entries = append(entries, timing{name: total, dur: time.Since(t.start)})
+
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		var b strings.Builder
+		b.WriteString(e.name)
+		if e.desc != "" {
+			b.WriteString(`;desc="`)
+			b.WriteString(strings.ReplaceAll(e.desc, `"`, `'`))
+			b.WriteString(`"`)
+		}
+		b.WriteString(";dur=")
+		b.WriteString(strconv.FormatFloat(float64(e.dur)/float64(time.Millisecond), 'f', 1, 64))
+		parts[i] = b.String()
+	}
+
+	h.Add("Server-Timing", strings.Join(parts, ", "))
+}
@@ -0,0 +1,48 @@
+package httpmw
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/hasahmad/go-helpers/timeutil"
+)
+
+type TimezoneOptions struct {
+	// Header carries the client's IANA timezone name. Defaults to "Time-Zone".
+	Header string
+	// FromContext returns a timezone name from earlier middleware, e.g. the
+	// authenticated user's profile. It is consulted when the header is absent or
+	// invalid.
+	FromContext func(ctx context.Context) string
+	// Default is used when neither source yields a valid timezone. Defaults to UTC.
+	Default *time.Location
+}
+
+// Timezone resolves the caller's timezone and stores it in the request context for
+// timeutil.InRequestTZ and timeutil.LocationFromContext. The resolved name is echoed
+// in the same header on the response.
+func Timezone(opts TimezoneOptions) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		opts.Header = "Time-Zone"
+	}
+	if opts.Default == nil {
+		opts.Default = time.UTC
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loc := opts.Default
+			if l, err := timeutil.ResolveLocation(r.Header.Get(opts.Header)); err == nil {
+				loc = l
+			} else if opts.FromContext != nil {
+				if l, err := timeutil.ResolveLocation(opts.FromContext(r.Context())); err == nil {
+					loc = l
+				}
+			}
+
+			w.Header().Set(opts.Header, loc.String())
+			next.ServeHTTP(w, r.WithContext(timeutil.WithLocation(r.Context(), loc)))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package httpmw
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// responseWriter wraps an http.ResponseWriter to record the status code and the number
+// of bytes written, optionally keeping a copy of the body.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+	body        *bytes.Buffer
+	maxBody     int
+	// beforeWriteHeader runs once, just before the headers are sent.
+	beforeWriteHeader func(status int)
+}
+
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.status = status
+	rw.wroteHeader = true
+	if rw.beforeWriteHeader != nil {
+		rw.beforeWriteHeader(status)
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.body != nil {
+		if rw.maxBody <= 0 {
+			rw.body.Write(b)
+		} else if room := rw.maxBody - rw.body.Len(); room > 0 {
+			if len(b) > room {
+				rw.body.Write(b[:room])
+			} else {
+				rw.body.Write(b)
+			}
+		}
+	}
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades and other protocol switches through the wrapper.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httpmw: underlying response writer does not support hijacking")
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err == nil {
+		// The connection now belongs to the caller; record it as switched so nothing
+		// tries to write a response.
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, brw, err
+}
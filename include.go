@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/validator"
+)
+
+// IncludeLoader loads one expansion of a resource, such as a post's author.
+type IncludeLoader func(ctx context.Context) (interface{}, error)
+
+// Includes is a registry of the expansions a handler can attach to its response,
+// requested with ?include=author,comments (or ?expand=):
+//
+//	inc := helpers.NewIncludes().
+//		Add("author", func(ctx context.Context) (interface{}, error) { return users.Get(ctx, post.AuthorID) }).
+//		Add("comments", func(ctx context.Context) (interface{}, error) { return comments.ForPost(ctx, post.ID) })
+//	names := inc.Read(r, v)
+//	if !v.Valid() { ... }
+//	env := helpers.Envelope{"post": post}
+//	if err := inc.Attach(r.Context(), env, names); err != nil { ... }
+//
+// The registered names double as the safelist.
+type Includes struct {
+	loaders map[string]IncludeLoader
+}
+
+func NewIncludes() *Includes {
+	return &Includes{loaders: map[string]IncludeLoader{}}
+}
+
+// Add registers loader under name and returns the registry for chaining.
+func (in *Includes) Add(name string, loader IncludeLoader) *Includes {
+	in.loaders[name] = loader
+	return in
+}
+
+// Names returns the registered expansions in sorted order.
+func (in *Includes) Names() []string {
+	names := make([]string, 0, len(in.loaders))
+	for name := range in.loaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Read reads the requested expansions, recording unknown ones in v.
+func (in *Includes) Read(r *http.Request, v *validator.Validator) []string {
+	return ReadIncludes(r, v, in.Names())
+}
+
+// Attach runs the loader of each requested expansion and stores its result in env
+// under the expansion's name. The first loader error is returned, naming the
+// expansion.
+func (in *Includes) Attach(ctx context.Context, env Envelope, names []string) error {
+	for _, name := range names {
+		loader, ok := in.loaders[name]
+		if !ok {
+			return fmt.Errorf("include %q is not registered", name)
+		}
+		val, err := loader(ctx)
+		if err != nil {
+			return fmt.Errorf("loading include %q: %w", name, err)
+		}
+		env[name] = val
+	}
+
+	return nil
+}
+
+// ReadIncludes reads the comma-separated include query parameter, or expand when
+// include is absent, checking each name against allowed. Unknown names are recorded
+// in v under the parameter's name and duplicates are dropped.
+func ReadIncludes(r *http.Request, v *validator.Validator, allowed []string) []string {
+	key := "include"
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		key = "expand"
+		s = r.URL.Query().Get(key)
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !validator.In(name, allowed...) {
+			v.AddError(key, "unknown include "+name)
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
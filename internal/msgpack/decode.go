@@ -0,0 +1,215 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var errShort = errors.New("msgpack: unexpected end of data")
+
+// Unmarshal decodes MessagePack into v the way encoding/json would decode the
+// equivalent JSON, so values written by Marshal round-trip.
+func Unmarshal(data []byte, v interface{}) error {
+	d := decoder{data: data}
+	generic, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data")
+	}
+
+	js, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, v)
+}
+
+// maxDepth bounds nesting so hostile input cannot exhaust the stack.
+const maxDepth = 1000
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.take(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf [8]byte
+	copy(buf[8-size:], b)
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *decoder) length(size int) (int, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	// Every element takes at least one byte, so longer lengths are corrupt.
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, errShort
+	}
+
+	return int(n), nil
+}
+
+func (d *decoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: nesting too deep")
+	}
+
+	tag, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	c := tag[0]
+
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.arrayOf(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.strPrefixed(1)
+	case 0xc5, 0xda:
+		return d.strPrefixed(2)
+	case 0xc6, 0xdb:
+		return d.strPrefixed(4)
+	case 0xca:
+		u, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float(float64(math.Float32frombits(uint32(u))))
+	case 0xcb:
+		u, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return float(math.Float64frombits(u))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the encoded width.
+		shift := 64 - 8*uint(size)
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), nil
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+
+	return nil, fmt.Errorf("msgpack: unsupported format 0x%02x", c)
+}
+
+func float(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("msgpack: %v has no JSON form", f)
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+func (d *decoder) str(n int) (interface{}, error) {
+	b, err := d.take(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (d *decoder) strPrefixed(size int) (interface{}, error) {
+	n, err := d.length(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.str(n)
+}
+
+func (d *decoder) arrayOf(n, depth int) (interface{}, error) {
+	out := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func (d *decoder) mapOf(n, depth int) (interface{}, error) {
+	out := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+
+	return out, nil
+}
@@ -0,0 +1,156 @@
+// Package msgpack converts between MessagePack and the JSON form of Go values, for the
+// response encoder and the cache codec.
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Marshal returns the JSON form of data as MessagePack.
+func Marshal(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encode writes the JSON form of data as MessagePack. Integers use the smallest
+// encoding that fits, other numbers are float64 and map keys are sorted.
+func Encode(w io.Writer, data interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeMsgPack(bw, v); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func writeMsgPack(w *bufio.Writer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		return w.WriteByte(0xc0)
+	case bool:
+		if val {
+			return w.WriteByte(0xc3)
+		}
+		return w.WriteByte(0xc2)
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return writeMsgPackInt(w, n)
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return err
+		}
+		w.WriteByte(0xcb)
+		return writeUint(w, math.Float64bits(f), 8)
+	case string:
+		writeMsgPackHeader(w, len(val), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		_, err := w.WriteString(val)
+		return err
+	case []interface{}:
+		writeMsgPackHeader(w, len(val), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range val {
+			if err := writeMsgPack(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		writeMsgPackHeader(w, len(val), 0x80, 16, 0, 0xde, 0xdf)
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := writeMsgPack(w, k); err != nil {
+				return err
+			}
+			if err := writeMsgPack(w, val[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("msgpack: unsupported type %T", v)
+}
+
+// writeMsgPackHeader writes a length prefix: the fix form below fixLimit, then the 8
+// (if the format has one), 16 and 32 bit forms.
+func writeMsgPackHeader(w *bufio.Writer, n int, fix byte, fixLimit int, b8, b16, b32 byte) {
+	switch {
+	case n < fixLimit:
+		w.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		w.WriteByte(b8)
+		w.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(b16)
+		writeUint(w, uint64(n), 2)
+	default:
+		w.WriteByte(b32)
+		writeUint(w, uint64(n), 4)
+	}
+}
+
+func writeMsgPackInt(w *bufio.Writer, n int64) error {
+	switch {
+	case n >= 0 && n < 128:
+		return w.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		return w.WriteByte(byte(int8(n)))
+	case n >= 0 && n <= math.MaxUint8:
+		w.WriteByte(0xcc)
+		return w.WriteByte(byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		w.WriteByte(0xcd)
+		return writeUint(w, uint64(n), 2)
+	case n >= 0 && n <= math.MaxUint32:
+		w.WriteByte(0xce)
+		return writeUint(w, uint64(n), 4)
+	case n >= 0:
+		w.WriteByte(0xcf)
+		return writeUint(w, uint64(n), 8)
+	case n >= math.MinInt8:
+		w.WriteByte(0xd0)
+		return w.WriteByte(byte(int8(n)))
+	case n >= math.MinInt16:
+		w.WriteByte(0xd1)
+		return writeUint(w, uint64(uint16(int16(n))), 2)
+	case n >= math.MinInt32:
+		w.WriteByte(0xd2)
+		return writeUint(w, uint64(uint32(int32(n))), 4)
+	}
+
+	w.WriteByte(0xd3)
+	return writeUint(w, uint64(n), 8)
+}
+
+func writeUint(w *bufio.Writer, v uint64, size int) error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	_, err := w.Write(b[8-size:])
+	return err
+}
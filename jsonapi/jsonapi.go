@@ -0,0 +1,191 @@
+// Package jsonapi writes responses in the JSON:API format
+// (https://jsonapi.org/format/) on top of helpers.Envelope and the filters
+// pagination metadata, for clients that expect that spec instead of plain envelopes.
+package jsonapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	helpers "github.com/hasahmad/go-helpers"
+	"github.com/hasahmad/go-helpers/filters"
+)
+
+// MediaType is the JSON:API content type.
+const MediaType = "application/vnd.api+json"
+
+// Resource is a resource object. Attributes is usually a struct or map without the
+// id field, which belongs in ID.
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    interface{}             `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         map[string]string       `json:"links,omitempty"`
+	Meta          map[string]interface{}  `json:"meta,omitempty"`
+}
+
+// Identifier is a resource identifier object, the type and id of a related resource.
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+func (r Resource) Identifier() Identifier {
+	return Identifier{Type: r.Type, ID: r.ID}
+}
+
+// Relationship links a resource to others. Data is an Identifier, a []Identifier or
+// nil for an empty to-one relationship; see ToOne and ToMany.
+type Relationship struct {
+	Data  interface{}       `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// ToOne returns a to-one relationship, empty when id is "".
+func ToOne(typ, id string) Relationship {
+	if id == "" {
+		return Relationship{}
+	}
+
+	return Relationship{Data: Identifier{Type: typ, ID: id}}
+}
+
+// ToMany returns a to-many relationship, serialised as [] when ids is empty.
+func ToMany(typ string, ids ...string) Relationship {
+	data := make([]Identifier, len(ids))
+	for i, id := range ids {
+		data[i] = Identifier{Type: typ, ID: id}
+	}
+
+	return Relationship{Data: data}
+}
+
+// Document is a top-level JSON:API document. Data is a Resource, a []Resource or nil.
+type Document struct {
+	Data     interface{}
+	Included []Resource
+	Meta     map[string]interface{}
+	Links    map[string]string
+}
+
+// Envelope returns the document as a helpers.Envelope. Included resources are
+// deduplicated by type and id.
+func (d Document) Envelope() helpers.Envelope {
+	env := helpers.Envelope{
+		"jsonapi": map[string]string{"version": "1.1"},
+		"data":    d.Data,
+	}
+	if len(d.Included) > 0 {
+		env["included"] = dedupe(d.Included)
+	}
+	if len(d.Meta) > 0 {
+		env["meta"] = d.Meta
+	}
+	if len(d.Links) > 0 {
+		env["links"] = d.Links
+	}
+
+	return env
+}
+
+// Paginate adds the pagination metadata to d's meta and first/prev/next/last links
+// built from the request URL, as filters.LinkHeader does.
+func (d *Document) Paginate(r *http.Request, m filters.Metadata) {
+	if d.Meta == nil {
+		d.Meta = map[string]interface{}{}
+	}
+	d.Meta["pagination"] = m
+
+	if d.Links == nil {
+		d.Links = map[string]string{}
+	}
+	for rel, u := range filters.ParseLinkHeader(filters.LinkHeader(r, m)) {
+		d.Links[rel] = u.String()
+	}
+}
+
+func dedupe(resources []Resource) []Resource {
+	seen := map[Identifier]bool{}
+	out := resources[:0:0]
+	for _, res := range resources {
+		if id := res.Identifier(); !seen[id] {
+			seen[id] = true
+			out = append(out, res)
+		}
+	}
+
+	return out
+}
+
+// Write sends doc with the JSON:API content type.
+func Write(w http.ResponseWriter, status int, doc Document, headers http.Header) error {
+	return write(w, status, doc.Envelope(), headers)
+}
+
+// ErrorObject is a JSON:API error object.
+type ErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ErrorSource points at the part of the request that caused an error.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// WriteErrors sends an errors document. Error objects without a Status get status.
+func WriteErrors(w http.ResponseWriter, status int, errs ...ErrorObject) error {
+	for i := range errs {
+		if errs[i].Status == "" {
+			errs[i].Status = strconv.Itoa(status)
+		}
+	}
+
+	env := helpers.Envelope{
+		"jsonapi": map[string]string{"version": "1.1"},
+		"errors":  errs,
+	}
+
+	return write(w, status, env, nil)
+}
+
+// ValidationErrors turns validator errors into error objects pointing at the
+// matching attribute, sorted by key.
+func ValidationErrors(errs map[string]string) []ErrorObject {
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]ErrorObject, len(keys))
+	for i, k := range keys {
+		out[i] = ErrorObject{
+			Status: strconv.Itoa(http.StatusUnprocessableEntity),
+			Title:  "Invalid Attribute",
+			Detail: errs[k],
+			Source: &ErrorSource{Pointer: "/data/attributes/" + k},
+		}
+	}
+
+	return out
+}
+
+func write(w http.ResponseWriter, status int, env helpers.Envelope, headers http.Header) error {
+	h := http.Header{}
+	for key, value := range headers {
+		h[key] = value
+	}
+	h.Set("Content-Type", MediaType)
+
+	return helpers.WriteJSON(w, status, env, h)
+}
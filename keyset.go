@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/filters"
+	"github.com/hasahmad/go-helpers/validator"
+)
+
+type KeysetOptions struct {
+	// Columns are the keyset columns in sort order, ending with a unique one, e.g.
+	// []string{"created_at", "id"}. They are written into SQL as is and must not come
+	// from user input.
+	Columns []string
+	// Descending pages from the newest rows to the oldest.
+	Descending bool
+	// Cursor seals the next_cursor tokens.
+	Cursor filters.CursorOptions
+	// DefaultLimit and MaxLimit bound the limit query parameter. They default to 20
+	// and 100.
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// KeysetPage ties together reading the cursor and limit query parameters, building
+// the SQL for the page and producing the response:
+//
+//	page := helpers.ReadKeysetPage[Movie](r, v, opts)
+//	if !v.Valid() { ... }
+//	where, args := page.Where()
+//	query := "SELECT ... FROM movies " + where + " ORDER BY " + page.OrderBy() + " LIMIT ?"
+//	movies, err := load(query, append(args, page.FetchLimit())...)
+//	env, err := page.Envelope(movies, func(m Movie) map[string]interface{} {
+//		return map[string]interface{}{"created_at": m.CreatedAt, "id": m.ID}
+//	})
+type KeysetPage[T any] struct {
+	Limit int
+	// After holds the decoded cursor values, or nil on the first page.
+	After map[string]interface{}
+	opts  KeysetOptions
+}
+
+// ReadKeysetPage reads the cursor and limit query parameters, recording problems in
+// v under those keys.
+func ReadKeysetPage[T any](r *http.Request, v *validator.Validator, opts KeysetOptions) *KeysetPage[T] {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 20
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+	p := &KeysetPage[T]{Limit: opts.DefaultLimit, opts: opts}
+
+	q := r.URL.Query()
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		switch {
+		case err != nil:
+			v.AddError("limit", "must be an integer value")
+		case n <= 0:
+			v.AddError("limit", "must be greater than zero")
+		case n > opts.MaxLimit:
+			v.AddError("limit", "must be a maximum of "+strconv.Itoa(opts.MaxLimit))
+		default:
+			p.Limit = n
+		}
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		after, err := decodeKeysetCursor(c, opts)
+		switch {
+		case errors.Is(err, filters.ErrExpiredCursor):
+			v.AddError("cursor", "has expired")
+		case err != nil:
+			v.AddError("cursor", "invalid cursor")
+		default:
+			p.After = after
+		}
+	}
+
+	return p
+}
+
+func decodeKeysetCursor(c string, opts KeysetOptions) (map[string]interface{}, error) {
+	raw, err := filters.DecodeCursor[map[string]json.RawMessage](c, opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	after := make(map[string]interface{}, len(opts.Columns))
+	for _, col := range opts.Columns {
+		msg, ok := raw[col]
+		if !ok {
+			return nil, filters.ErrInvalidCursor
+		}
+		// Decode numbers as int64 where possible so large ids keep their precision.
+		dec := json.NewDecoder(bytes.NewReader(msg))
+		dec.UseNumber()
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return nil, filters.ErrInvalidCursor
+		}
+		if n, ok := val.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				val = i
+			} else {
+				val, _ = n.Float64()
+			}
+		}
+		after[col] = val
+	}
+
+	return after, nil
+}
+
+// Where returns the row comparison that starts the page after the cursor, with ?
+// placeholders, e.g. "WHERE (created_at, id) < (?, ?)". It is empty on the first page.
+func (p *KeysetPage[T]) Where() (string, []interface{}) {
+	if p.After == nil || len(p.opts.Columns) == 0 {
+		return "", nil
+	}
+
+	op := ">"
+	if p.opts.Descending {
+		op = "<"
+	}
+	placeholders := make([]string, len(p.opts.Columns))
+	args := make([]interface{}, len(p.opts.Columns))
+	for i, col := range p.opts.Columns {
+		placeholders[i] = "?"
+		args[i] = p.After[col]
+	}
+
+	return "WHERE (" + strings.Join(p.opts.Columns, ", ") + ") " + op + " (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// OrderBy returns the ORDER BY list matching the keyset direction.
+func (p *KeysetPage[T]) OrderBy() string {
+	dir := " ASC"
+	if p.opts.Descending {
+		dir = " DESC"
+	}
+
+	cols := make([]string, len(p.opts.Columns))
+	for i, col := range p.opts.Columns {
+		cols[i] = col + dir
+	}
+
+	return strings.Join(cols, ", ")
+}
+
+// FetchLimit is the LIMIT to query with: one more than the page size, so Envelope can
+// tell whether there is a next page.
+func (p *KeysetPage[T]) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// Envelope trims the extra row fetched by FetchLimit and returns
+// {"items": [...], "next_cursor": "..."}, with next_cursor null on the last page. key
+// returns the keyset column values of an item.
+func (p *KeysetPage[T]) Envelope(items []T, key func(T) map[string]interface{}) (Envelope, error) {
+	var next interface{}
+	if len(items) > p.Limit {
+		items = items[:p.Limit]
+
+		c, err := filters.EncodeCursor(key(items[len(items)-1]), p.opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		next = c
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	return Envelope{"items": items, "next_cursor": next}, nil
+}
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hasahmad/go-helpers/filters"
+)
+
+// Link is a HAL link object. Templated is set when the href still has {placeholders}.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+// Links builds the HAL _links of a response from chi route patterns and the request's
+// base URL:
+//
+//	env := helpers.NewLinks(r, helpers.LinksOptions{}).
+//		Self().
+//		Add("director", "/v1/people/{id}", "id", strconv.FormatInt(movie.DirectorID, 10)).
+//		Add("reviews", "/v1/movies/{id}/reviews", "id", strconv.FormatInt(movie.ID, 10)).
+//		Attach(helpers.Envelope{"movie": movie})
+type Links struct {
+	r     *http.Request
+	base  string
+	links map[string]Link
+}
+
+type LinksOptions struct {
+	// TrustedProxies are the CIDRs whose X-Forwarded-Proto and X-Forwarded-Host
+	// headers are believed. Without them the headers are ignored, as any client could
+	// send them.
+	TrustedProxies []string
+}
+
+// NewLinks starts a set of links for r. The base URL is taken from the request,
+// honouring X-Forwarded-Proto and X-Forwarded-Host when r comes from one of
+// opts.TrustedProxies. It panics on an invalid CIDR.
+func NewLinks(r *http.Request, opts LinksOptions) *Links {
+	return &Links{r: r, base: baseURL(r, fromTrustedProxy(r, opts.TrustedProxies)), links: map[string]Link{}}
+}
+
+func fromTrustedProxy(r *http.Request, proxies []string) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+
+	for _, cidr := range proxies {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("helpers: invalid trusted proxy " + cidr)
+		}
+		if ip != nil && n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func baseURL(r *http.Request, trustForwarded bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if trustForwarded {
+		if p := r.Header.Get("X-Forwarded-Proto"); p != "" {
+			scheme, _, _ = strings.Cut(p, ",")
+		}
+		if h := r.Header.Get("X-Forwarded-Host"); h != "" {
+			host, _, _ = strings.Cut(h, ",")
+		}
+	}
+
+	return strings.TrimSpace(scheme) + "://" + strings.TrimSpace(host)
+}
+
+// Self links to the current route, rebuilt from its chi pattern and URL parameters
+// with the query string preserved.
+func (l *Links) Self() *Links {
+	path := l.r.URL.Path
+	if rctx := chi.RouteContext(l.r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" && !strings.HasSuffix(pattern, "*") {
+			var kv []string
+			for i, k := range rctx.URLParams.Keys {
+				kv = append(kv, k, rctx.URLParams.Values[i])
+			}
+			path, _ = expandPattern(pattern, kv)
+		}
+	}
+
+	href := l.base + path
+	if l.r.URL.RawQuery != "" {
+		href += "?" + l.r.URL.RawQuery
+	}
+	l.links["self"] = Link{Href: href}
+
+	return l
+}
+
+// Add links rel to a chi route pattern such as "/v1/movies/{id}", filling its
+// parameters from kv, which alternates names and values. Parameters left unfilled
+// make the link templated.
+func (l *Links) Add(rel, pattern string, kv ...string) *Links {
+	if len(kv)%2 != 0 {
+		panic("helpers: Links.Add needs name/value pairs")
+	}
+
+	path, templated := expandPattern(pattern, kv)
+	l.links[rel] = Link{Href: l.base + path, Templated: templated}
+
+	return l
+}
+
+// AddHref links rel to href as is, for links outside this service.
+func (l *Links) AddHref(rel, href string) *Links {
+	l.links[rel] = Link{Href: href}
+	return l
+}
+
+// Paginate adds first, prev, next and last links for page based pagination, as
+// filters.LinkHeader builds them.
+func (l *Links) Paginate(m filters.Metadata) *Links {
+	for rel, u := range filters.ParseLinkHeader(filters.LinkHeader(l.r, m)) {
+		l.links[rel] = Link{Href: l.base + u.RequestURI()}
+	}
+
+	return l
+}
+
+// Map returns the links keyed by relation.
+func (l *Links) Map() map[string]Link {
+	return l.links
+}
+
+// Attach sets env["_links"] and returns env.
+func (l *Links) Attach(env Envelope) Envelope {
+	env["_links"] = l.links
+	return env
+}
+
+// expandPattern replaces {name} and {name:regexp} segments of a chi pattern with
+// escaped values from kv, and drops a trailing /* wildcard. It reports whether any
+// placeholder was left in place.
+func expandPattern(pattern string, kv []string) (string, bool) {
+	values := make(map[string]string, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		values[kv[i]] = kv[i+1]
+	}
+
+	var b strings.Builder
+	templated := false
+	rest := strings.TrimSuffix(pattern, "/*")
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end += start
+
+		b.WriteString(rest[:start])
+		name, _, _ := strings.Cut(rest[start+1:end], ":")
+		if v, ok := values[name]; ok {
+			b.WriteString(url.PathEscape(v))
+		} else {
+			b.WriteString("{" + name + "}")
+			templated = true
+		}
+		rest = rest[end+1:]
+	}
+
+	return b.String(), templated
+}
@@ -0,0 +1,135 @@
+// Package lock provides distributed locks, so a cron job or migration running on
+// several instances executes on one of them at a time. Locks are backed by Postgres
+// advisory locks or Redis.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when another holder has the lock.
+	ErrLocked = errors.New("lock: held by another process")
+	// ErrLockLost is returned when a held lock could not be renewed.
+	ErrLockLost = errors.New("lock: lost")
+)
+
+// Locker acquires named locks.
+type Locker interface {
+	// TryAcquire takes the lock for ttl without waiting, returning ErrLocked when it is
+	// held elsewhere.
+	TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error)
+}
+
+// Lease is a held lock.
+type Lease interface {
+	// Renew extends the lock by its ttl, returning ErrLockLost when it is no longer
+	// held.
+	Renew(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// WithLock runs fn while holding the lock name, renewing it every third of ttl. It
+// returns ErrLocked without running fn when the lock is held elsewhere, which
+// scheduled jobs usually treat as "another instance is on it":
+//
+//	err := lock.WithLock(ctx, locker, "nightly-report", time.Minute, sendReport)
+//	if errors.Is(err, lock.ErrLocked) {
+//		return nil
+//	}
+//
+// When a renewal fails the context passed to fn is cancelled and WithLock returns an
+// error wrapping both ErrLockLost and fn's error once fn returns, since another
+// instance may have taken over. Otherwise it returns fn's error.
+func WithLock(ctx context.Context, l Locker, name string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	if ttl <= 0 {
+		panic("lock: ttl must be positive")
+	}
+
+	lease, err := l.TryAcquire(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan error, 1)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(ttl / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-fnCtx.Done():
+				return
+			case <-t.C:
+				rctx, rcancel := context.WithTimeout(fnCtx, ttl/3)
+				err := lease.Renew(rctx)
+				rcancel()
+				if err != nil && fnCtx.Err() == nil {
+					lost <- err
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	ferr := fn(fnCtx)
+	close(done)
+	// A renewal may still be running; the lease must not be used concurrently.
+	<-exited
+
+	// Release with a fresh context, as ctx may be what ended fn.
+	rctx, rcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer rcancel()
+
+	select {
+	case err := <-lost:
+		lease.Release(rctx)
+		return &lostError{name: name, cause: err, fnErr: ferr}
+	default:
+	}
+
+	if err := lease.Release(rctx); err != nil && ferr == nil {
+		return err
+	}
+
+	return ferr
+}
+
+// lostError reports a lock lost while fn ran, together with what fn returned. It
+// matches ErrLockLost and the renewal error, and unwraps to fn's error.
+type lostError struct {
+	name  string
+	cause error
+	fnErr error
+}
+
+func (e *lostError) Error() string {
+	msg := fmt.Sprintf("lock %q: %v", e.name, ErrLockLost)
+	if !errors.Is(e.cause, ErrLockLost) {
+		msg += ": " + e.cause.Error()
+	}
+	if e.fnErr != nil {
+		msg += "; fn: " + e.fnErr.Error()
+	}
+
+	return msg
+}
+
+func (e *lostError) Is(target error) bool {
+	return target == ErrLockLost || errors.Is(e.cause, target)
+}
+
+func (e *lostError) Unwrap() error {
+	return e.fnErr
+}
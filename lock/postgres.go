@@ -0,0 +1,76 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"time"
+)
+
+// Postgres is a Locker backed by session-level advisory locks. Each lease holds a
+// connection from db for its lifetime, and the lock goes away with the session when
+// the process dies, so ttl only sets how often the connection is checked.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// AdvisoryKey maps a lock name to the bigint key passed to pg_try_advisory_lock.
+func AdvisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return int64(h.Sum64())
+}
+
+func (p *Postgres) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := AdvisoryKey(name)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, ErrLocked
+	}
+
+	return &pgLease{conn: conn, key: key}, nil
+}
+
+type pgLease struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Renew checks that the session still holds the lock; a dropped connection means it
+// has been released.
+func (l *pgLease) Renew(ctx context.Context) error {
+	var held bool
+	err := l.conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid() AND granted AND objsubid = 1"+
+			" AND ((classid::bigint << 32) | objid::bigint) = $1)", l.key).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (l *pgLease) Release(ctx context.Context) error {
+	defer l.conn.Close()
+
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	return err
+}
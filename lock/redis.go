@@ -0,0 +1,88 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RedisDoer sends a raw command, as cache.RedisConn.Do does.
+type RedisDoer interface {
+	Do(ctx context.Context, args ...string) (interface{}, error)
+}
+
+// Redis is a Locker backed by a Redis key per lock, set with NX and a ttl and holding
+// a random token so only the holder can renew or delete it. If the holder stops
+// renewing, the key expires after ttl and another instance can take the lock.
+type Redis struct {
+	client RedisDoer
+	// Prefix is prepended to lock names. Defaults to "lock:".
+	Prefix string
+}
+
+func NewRedis(client RedisDoer) *Redis {
+	return &Redis{client: client, Prefix: "lock:"}
+}
+
+const (
+	redisRenewScript   = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`
+	redisReleaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+)
+
+func (r *Redis) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	l := &redisLease{client: r.client, key: r.Prefix + name, token: hex.EncodeToString(b), ttl: ttl}
+	reply, err := r.client.Do(ctx, "SET", l.key, l.token, "NX", "PX", l.ms())
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrLocked
+	}
+
+	return l, nil
+}
+
+type redisLease struct {
+	client RedisDoer
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+func (l *redisLease) ms() string {
+	ms := l.ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+
+	return strconv.FormatInt(ms, 10)
+}
+
+func (l *redisLease) Renew(ctx context.Context) error {
+	reply, err := l.client.Do(ctx, "EVAL", redisRenewScript, "1", l.key, l.token, l.ms())
+	if err != nil {
+		return err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return fmt.Errorf("lock: unexpected EVAL reply %T", reply)
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (l *redisLease) Release(ctx context.Context) error {
+	_, err := l.client.Do(ctx, "EVAL", redisReleaseScript, "1", l.key, l.token)
+	return err
+}
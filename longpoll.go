@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LongPoll holds the request until waitFor reports data or timeout elapses. waitFor
+// should block until it has something to send or ctx is done, returning false in the
+// latter case. Data is sent with 200 OK; a timeout answers 204 No Content so the client
+// polls again. If the client goes away nothing is written.
+func LongPoll(w http.ResponseWriter, r *http.Request, waitFor func(ctx context.Context) (Envelope, bool), timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	data, ok := waitFor(ctx)
+	if r.Context().Err() != nil {
+		return r.Context().Err()
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	return WriteJSON(w, http.StatusOK, data, nil)
+}
+
+// Notifier wakes every goroutine waiting on it when Notify is called, which pairs with
+// LongPoll: handlers Wait for a change, writers Notify after making one.
+type Notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// Changed returns a channel which is closed on the next Notify.
+func (n *Notifier) Changed() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+// Wait blocks until the next Notify or until ctx is done, reporting which happened.
+func (n *Notifier) Wait(ctx context.Context) bool {
+	select {
+	case <-n.Changed():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Notify wakes all current waiters.
+func (n *Notifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
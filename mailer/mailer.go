@@ -0,0 +1,324 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// DefaultTemplates holds the templates bundled with the package, currently
+// "user_activation.tmpl" which expects an ActivationToken field.
+var DefaultTemplates, _ = fs.Sub(templatesFS, "templates")
+
+type Options struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Sender is the From address, e.g. "Acme <no-reply@acme.test>".
+	Sender string
+	// Templates holds the email templates, typically an embed.FS. Each template file
+	// defines "subject", "plainBody" and optionally "htmlBody". Defaults to
+	// DefaultTemplates.
+	Templates fs.FS
+	// Timeout bounds each delivery attempt. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a failed delivery. Defaults to 3;
+	// set it negative to disable retries.
+	Retries int
+	// Backoff is the delay before the first retry, doubling each time. Defaults to
+	// 500 milliseconds.
+	Backoff time.Duration
+	// DryRun renders messages and writes them to DryRunOutput (os.Stdout if nil)
+	// instead of sending them.
+	DryRun       bool
+	DryRunOutput io.Writer
+}
+
+// Message is a rendered email.
+type Message struct {
+	To        string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+}
+
+type Mailer struct {
+	opts Options
+}
+
+func New(opts Options) *Mailer {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	} else if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 500 * time.Millisecond
+	}
+	if opts.Templates == nil {
+		opts.Templates = DefaultTemplates
+	}
+	if opts.DryRunOutput == nil {
+		opts.DryRunOutput = os.Stdout
+	}
+
+	return &Mailer{opts: opts}
+}
+
+// Render executes templateFile with data. The plain and subject parts use
+// text/template; the HTML part uses html/template so data is escaped. A missing
+// templateFile gives an error matching fs.ErrNotExist.
+func (m *Mailer) Render(recipient, templateFile string, data interface{}) (*Message, error) {
+	tmpl, err := template.New("email").ParseFS(m.opts.Templates, templateFile)
+	if err != nil {
+		// ParseFS does not wrap the not-found case.
+		if _, serr := fs.Stat(m.opts.Templates, templateFile); errors.Is(serr, fs.ErrNotExist) {
+			return nil, fmt.Errorf("mailer: template %s: %w", templateFile, serr)
+		}
+		return nil, err
+	}
+
+	msg := &Message{To: recipient}
+
+	subject := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+
+	plainBody := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(plainBody, "plainBody", data); err != nil {
+		return nil, err
+	}
+	msg.PlainBody = plainBody.String()
+
+	htmlTmpl, err := htmltemplate.New("email").ParseFS(m.opts.Templates, templateFile)
+	if err != nil {
+		return nil, err
+	}
+	if htmlTmpl.Lookup("htmlBody") != nil {
+		htmlBody := new(bytes.Buffer)
+		if err := htmlTmpl.ExecuteTemplate(htmlBody, "htmlBody", data); err != nil {
+			return nil, err
+		}
+		msg.HTMLBody = htmlBody.String()
+	}
+
+	return msg, nil
+}
+
+// Send renders templateFile for recipient and delivers it.
+func (m *Mailer) Send(recipient, templateFile string, data interface{}) error {
+	return m.SendContext(context.Background(), recipient, templateFile, data)
+}
+
+// SendContext is Send with a context bounding the whole operation, retries included.
+func (m *Mailer) SendContext(ctx context.Context, recipient, templateFile string, data interface{}) error {
+	msg, err := m.Render(recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	return m.SendMessage(ctx, msg)
+}
+
+// SendMessage delivers an already rendered message, retrying with exponential backoff.
+func (m *Mailer) SendMessage(ctx context.Context, msg *Message) error {
+	raw, err := m.build(msg)
+	if err != nil {
+		return err
+	}
+
+	if m.opts.DryRun {
+		_, err := fmt.Fprintf(m.opts.DryRunOutput, "--- dry run: email to %s ---\n%s\n", msg.To, raw)
+		return err
+	}
+
+	backoff := m.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err = m.deliver(ctx, msg.To, raw)
+		if err == nil || attempt >= m.opts.Retries || isPermanent(err) {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isPermanent reports whether err is an SMTP 5xx reply, which retrying won't fix.
+func isPermanent(err error) bool {
+	var tpErr *textproto.Error
+	return errors.As(err, &tpErr) && tpErr.Code >= 500
+}
+
+func (m *Mailer) deliver(ctx context.Context, to string, raw []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(m.opts.Host, strconv.Itoa(m.opts.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	// Port 465 speaks TLS from the first byte; everything else upgrades via STARTTLS.
+	if m.opts.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.opts.Host})
+	}
+
+	c, err := smtp.NewClient(conn, m.opts.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && m.opts.Port != 465 {
+		if err := c.StartTLS(&tls.Config{ServerName: m.opts.Host}); err != nil {
+			return err
+		}
+	}
+	if m.opts.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := mail.ParseAddress(m.opts.Sender)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid sender: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid recipient: %w", err)
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// build assembles the MIME message: a single text/plain part, or multipart/alternative
+// with plain and HTML parts.
+func (m *Mailer) build(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(m.opts.Sender); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+
+	header := func(k, v string) {
+		buf.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", m.opts.Sender)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+
+	if msg.HTMLBody == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQP(&buf, msg.PlainBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{`text/plain; charset="utf-8"`, msg.PlainBody},
+		{`text/html; charset="utf-8"`, msg.HTMLBody},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
@@ -0,0 +1,455 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/clock"
+	"github.com/hasahmad/go-helpers/ratelimit"
+)
+
+// Job is a queued email.
+type Job struct {
+	ID       string          `json:"id"`
+	To       string          `json:"to"`
+	Template string          `json:"template"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Attempts int             `json:"attempts"`
+	// NextAttempt is when the job becomes due.
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// QueueStore persists queued jobs so they survive a restart.
+type QueueStore interface {
+	Save(job Job) error
+	Delete(id string) error
+	Pending() ([]Job, error)
+}
+
+// MemoryQueueStore keeps jobs in memory. Jobs are lost on restart.
+type MemoryQueueStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryQueueStore) Save(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryQueueStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryQueueStore) Pending() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// FileQueueStore keeps one JSON file per job in a directory.
+type FileQueueStore struct {
+	dir string
+}
+
+func NewFileQueueStore(dir string) (*FileQueueStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileQueueStore{dir: dir}, nil
+}
+
+func (s *FileQueueStore) Save(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a half-written job behind.
+	tmp := filepath.Join(s.dir, job.ID+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(s.dir, job.ID+".json"))
+}
+
+func (s *FileQueueStore) Delete(id string) error {
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *FileQueueStore) Pending() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var j Job
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+type QueueOptions struct {
+	// Store persists jobs. Defaults to a MemoryQueueStore.
+	Store QueueStore
+	// Workers is the number of concurrent senders. Defaults to 2.
+	Workers int
+	// MaxAttempts is how often a job is tried before OnFailure is called and the job
+	// dropped. Defaults to 5.
+	MaxAttempts int
+	// RetryBackoff is the delay after the first failure, doubling per attempt up to one
+	// hour. Defaults to 30 seconds.
+	RetryBackoff time.Duration
+	// PollInterval is how often the store is checked for due jobs. Defaults to 1 second.
+	PollInterval time.Duration
+	// PerRecipientRate and PerDomainRate cap messages per second to one address and
+	// to one recipient domain. Zero disables the limit. Limited jobs are postponed
+	// without using up an attempt. Limiters of addresses and domains not seen for ten
+	// minutes, or the time their bucket takes to refill if longer, are dropped.
+	PerRecipientRate  float64
+	PerRecipientBurst int
+	PerDomainRate     float64
+	PerDomainBurst    int
+	// OnSent is called after a job is delivered.
+	OnSent func(job Job)
+	// OnFailure is called when a job is dropped after MaxAttempts or a permanent
+	// (5xx) SMTP error, e.g. to flag the address as bouncing.
+	OnFailure func(job Job, err error)
+	// Clock defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Queue delivers mail in the background so handlers can enqueue and return straight
+// away. Jobs are persisted before Enqueue returns and reloaded by Run, so nothing is
+// lost across restarts when a durable store is used.
+type Queue struct {
+	mailer *Mailer
+	opts   QueueOptions
+
+	mu        sync.Mutex
+	inFlight  map[string]bool
+	limiters  map[string]*queueLimiter
+	lastSweep time.Time
+	wake      chan struct{}
+}
+
+type queueLimiter struct {
+	*ratelimit.Limiter
+	lastSeen time.Time
+	ttl      time.Duration
+}
+
+func NewQueue(m *Mailer, opts QueueOptions) *Queue {
+	if opts.Store == nil {
+		opts.Store = NewMemoryQueueStore()
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 30 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	return &Queue{
+		mailer:    m,
+		opts:      opts,
+		inFlight:  make(map[string]bool),
+		limiters:  make(map[string]*queueLimiter),
+		lastSweep: opts.Clock.Now(),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Enqueue persists an email for background delivery. data must be JSON serializable;
+// templates see it decoded into maps and slices.
+func (q *Queue) Enqueue(recipient, templateFile string, data interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	job := Job{
+		ID:          hex.EncodeToString(id),
+		To:          recipient,
+		Template:    templateFile,
+		Data:        raw,
+		NextAttempt: q.opts.Clock.Now(),
+	}
+	if err := q.opts.Store.Save(job); err != nil {
+		return "", err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return job.ID, nil
+}
+
+// Run delivers jobs until ctx is done, then waits for in-flight deliveries to finish.
+func (q *Queue) Run(ctx context.Context) error {
+	jobs := make(chan Job)
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				q.process(job)
+			}
+		}()
+	}
+
+	ticker := q.opts.Clock.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	var err error
+loop:
+	for {
+		due, perr := q.due()
+		if perr != nil {
+			err = perr
+			break
+		}
+		for _, job := range due {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				q.release(job.ID)
+				break loop
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C():
+		case <-q.wake:
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return err
+}
+
+// due returns pending jobs whose time has come and marks them in flight.
+func (q *Queue) due() ([]Job, error) {
+	pending, err := q.opts.Store.Pending()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].NextAttempt.Before(pending[j].NextAttempt)
+	})
+
+	now := q.opts.Clock.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sweepLimiters(now)
+
+	var due []Job
+	for _, j := range pending {
+		if q.inFlight[j.ID] || j.NextAttempt.After(now) {
+			continue
+		}
+		q.inFlight[j.ID] = true
+		due = append(due, j)
+	}
+
+	return due, nil
+}
+
+func (q *Queue) release(id string) {
+	q.mu.Lock()
+	delete(q.inFlight, id)
+	q.mu.Unlock()
+}
+
+// limiterIdle is how long an unused limiter is kept at least.
+const limiterIdle = 10 * time.Minute
+
+// limiter returns the limiter for key, creating it if needed. The caller must hold the
+// mutex.
+func (q *Queue) limiter(key string, rate float64, burst int, now time.Time) *ratelimit.Limiter {
+	l, ok := q.limiters[key]
+	if !ok {
+		ttl := limiterIdle
+		if refill := time.Duration(float64(burst) / rate * float64(time.Second)); refill > ttl {
+			ttl = refill
+		}
+		l = &queueLimiter{Limiter: ratelimit.NewWithClock(rate, burst, q.opts.Clock), ttl: ttl}
+		q.limiters[key] = l
+	}
+	l.lastSeen = now
+
+	return l.Limiter
+}
+
+// sweepLimiters drops idle limiters, at most once a minute. The caller must hold the
+// mutex.
+func (q *Queue) sweepLimiters(now time.Time) {
+	if now.Sub(q.lastSweep) < time.Minute {
+		return
+	}
+	q.lastSweep = now
+
+	for k, l := range q.limiters {
+		if now.Sub(l.lastSeen) > l.ttl {
+			delete(q.limiters, k)
+		}
+	}
+}
+
+// allowed applies the per-recipient and per-domain limits, taking a token from each
+// only when both allow the message.
+func (q *Queue) allowed(to string) bool {
+	addr := strings.ToLower(to)
+	now := q.opts.Clock.Now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var limiters []*ratelimit.Limiter
+	if q.opts.PerRecipientRate > 0 {
+		limiters = append(limiters, q.limiter("rcpt:"+addr, q.opts.PerRecipientRate, q.opts.PerRecipientBurst, now))
+	}
+	if q.opts.PerDomainRate > 0 {
+		domain := addr[strings.LastIndex(addr, "@")+1:]
+		limiters = append(limiters, q.limiter("domain:"+domain, q.opts.PerDomainRate, q.opts.PerDomainBurst, now))
+	}
+
+	for _, l := range limiters {
+		if l.Tokens() < 1 {
+			return false
+		}
+	}
+	for _, l := range limiters {
+		l.Allow()
+	}
+
+	return true
+}
+
+// sendTimeout bounds one SendContext call: every attempt plus the backoff between them.
+func (q *Queue) sendTimeout() time.Duration {
+	o := q.mailer.opts
+	total := o.Timeout * time.Duration(o.Retries+1)
+	backoff := o.Backoff
+	for i := 0; i < o.Retries; i++ {
+		total += backoff
+		backoff *= 2
+	}
+
+	return total
+}
+
+func (q *Queue) process(job Job) {
+	defer q.release(job.ID)
+
+	if !q.allowed(job.To) {
+		job.NextAttempt = q.opts.Clock.Now().Add(q.opts.PollInterval)
+		q.opts.Store.Save(job)
+		return
+	}
+
+	var data interface{}
+	if len(job.Data) > 0 {
+		if err := json.Unmarshal(job.Data, &data); err != nil {
+			q.fail(job, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.sendTimeout())
+	defer cancel()
+
+	err := q.mailer.SendContext(ctx, job.To, job.Template, data)
+	if err == nil {
+		q.opts.Store.Delete(job.ID)
+		if q.opts.OnSent != nil {
+			q.opts.OnSent(job)
+		}
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	if job.Attempts >= q.opts.MaxAttempts || isPermanent(err) {
+		q.fail(job, err)
+		return
+	}
+
+	backoff := q.opts.RetryBackoff << (job.Attempts - 1)
+	if backoff > time.Hour || backoff <= 0 {
+		backoff = time.Hour
+	}
+	job.NextAttempt = q.opts.Clock.Now().Add(backoff)
+	q.opts.Store.Save(job)
+}
+
+func (q *Queue) fail(job Job, err error) {
+	q.opts.Store.Delete(job.ID)
+	if q.opts.OnFailure != nil {
+		q.opts.OnFailure(job, err)
+	}
+}
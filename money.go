@@ -0,0 +1,266 @@
+package helpers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Currency is how an ISO 4217 currency is written.
+type Currency struct {
+	Symbol string
+	// Digits is the number of minor unit digits: 2 for USD, 0 for JPY, 3 for KWD.
+	Digits int
+}
+
+// NumberLocale holds the separators and currency layout of a locale.
+type NumberLocale struct {
+	Decimal string
+	Group   string
+	// GroupSize digits are grouped from the right, then SecondaryGroupSize if set
+	// (2 for the Indian 12,34,567).
+	GroupSize          int
+	SecondaryGroupSize int
+	// MinGrouping leaves numbers with fewer integer digits ungrouped, e.g. 5 for
+	// Spanish, which writes 1234 but 12.345.
+	MinGrouping int
+	// CurrencyPattern places the symbol, ¤, around the number, #, e.g. "¤#" or
+	// "# ¤".
+	CurrencyPattern string
+	// NegativePattern is used for negative amounts. Defaults to "-" before
+	// CurrencyPattern.
+	NegativePattern string
+	// Symbols override the default symbol of a currency, e.g. "$" for CAD in en-CA.
+	Symbols map[string]string
+}
+
+const nbsp = "\u00a0"
+
+var (
+	currenciesMu sync.RWMutex
+	currencies   = map[string]Currency{
+		"AED": {"AED", 2}, "ARS": {"ARS", 2}, "AUD": {"A$", 2}, "BHD": {"BHD", 3},
+		"BRL": {"R$", 2}, "CAD": {"CA$", 2}, "CHF": {"CHF", 2}, "CLP": {"CLP", 0},
+		"CNY": {"CN¥", 2}, "COP": {"COP", 2}, "CZK": {"CZK", 2}, "DKK": {"DKK", 2},
+		"EGP": {"EGP", 2}, "EUR": {"€", 2}, "GBP": {"£", 2}, "HKD": {"HK$", 2},
+		"HUF": {"HUF", 2}, "IDR": {"IDR", 2}, "ILS": {"₪", 2}, "INR": {"₹", 2},
+		"ISK": {"ISK", 0}, "JOD": {"JOD", 3}, "JPY": {"¥", 0}, "KRW": {"₩", 0},
+		"KWD": {"KWD", 3}, "MXN": {"MX$", 2}, "MYR": {"MYR", 2}, "NGN": {"NGN", 2},
+		"NOK": {"NOK", 2}, "NZD": {"NZ$", 2}, "OMR": {"OMR", 3}, "PHP": {"₱", 2},
+		"PKR": {"PKR", 2}, "PLN": {"PLN", 2}, "QAR": {"QAR", 2}, "SAR": {"SAR", 2},
+		"SEK": {"SEK", 2}, "SGD": {"SGD", 2}, "THB": {"THB", 2}, "TND": {"TND", 3},
+		"TRY": {"TRY", 2}, "TWD": {"NT$", 2}, "UAH": {"UAH", 2}, "USD": {"$", 2},
+		"VND": {"₫", 0}, "ZAR": {"ZAR", 2},
+	}
+
+	numberLocalesMu sync.RWMutex
+	numberLocales   = map[string]NumberLocale{
+		"en":    {Decimal: ".", Group: ",", CurrencyPattern: "¤#"},
+		"en-ca": {Decimal: ".", Group: ",", CurrencyPattern: "¤#", Symbols: map[string]string{"CAD": "$", "USD": "US$"}},
+		"en-au": {Decimal: ".", Group: ",", CurrencyPattern: "¤#", Symbols: map[string]string{"AUD": "$", "USD": "US$"}},
+		"en-in": {Decimal: ".", Group: ",", SecondaryGroupSize: 2, CurrencyPattern: "¤#"},
+		"hi":    {Decimal: ".", Group: ",", SecondaryGroupSize: 2, CurrencyPattern: "¤#"},
+		"de":    {Decimal: ",", Group: ".", CurrencyPattern: "#" + nbsp + "¤"},
+		"de-ch": {Decimal: ".", Group: "’", CurrencyPattern: "¤" + nbsp + "#", NegativePattern: "¤-#"},
+		"fr":    {Decimal: ",", Group: "\u202f", CurrencyPattern: "#" + nbsp + "¤"},
+		"fr-ca": {Decimal: ",", Group: nbsp, CurrencyPattern: "#" + nbsp + "¤", Symbols: map[string]string{"CAD": "$", "USD": "$" + nbsp + "US"}},
+		"es":    {Decimal: ",", Group: ".", MinGrouping: 5, CurrencyPattern: "#" + nbsp + "¤"},
+		"es-mx": {Decimal: ".", Group: ",", CurrencyPattern: "¤#", Symbols: map[string]string{"MXN": "$", "USD": "USD"}},
+		"it":    {Decimal: ",", Group: ".", CurrencyPattern: "#" + nbsp + "¤"},
+		"nl":    {Decimal: ",", Group: ".", CurrencyPattern: "¤" + nbsp + "#", NegativePattern: "¤" + nbsp + "-#"},
+		"pl":    {Decimal: ",", Group: nbsp, MinGrouping: 5, CurrencyPattern: "#" + nbsp + "¤", Symbols: map[string]string{"PLN": "zł"}},
+		"pt":    {Decimal: ",", Group: nbsp, CurrencyPattern: "#" + nbsp + "¤"},
+		"pt-br": {Decimal: ",", Group: ".", CurrencyPattern: "¤" + nbsp + "#"},
+		"sv":    {Decimal: ",", Group: nbsp, CurrencyPattern: "#" + nbsp + "¤", Symbols: map[string]string{"SEK": "kr"}},
+		"ja":    {Decimal: ".", Group: ",", CurrencyPattern: "¤#", Symbols: map[string]string{"JPY": "￥", "CNY": "元"}},
+		"zh":    {Decimal: ".", Group: ",", CurrencyPattern: "¤#", Symbols: map[string]string{"CNY": "¥", "JPY": "JP¥"}},
+	}
+)
+
+// RegisterCurrency adds or replaces a currency, keyed by ISO 4217 code.
+func RegisterCurrency(code string, c Currency) {
+	currenciesMu.Lock()
+	defer currenciesMu.Unlock()
+
+	currencies[strings.ToUpper(code)] = c
+}
+
+// LookupCurrency returns a currency by code. Unknown codes are written as the code
+// itself with 2 digits.
+func LookupCurrency(code string) Currency {
+	currenciesMu.RLock()
+	defer currenciesMu.RUnlock()
+
+	code = strings.ToUpper(code)
+	if c, ok := currencies[code]; ok {
+		return c
+	}
+
+	return Currency{Symbol: code, Digits: 2}
+}
+
+// RegisterNumberLocale adds or replaces a locale, keyed by language tag such as "pt-BR".
+func RegisterNumberLocale(tag string, l NumberLocale) {
+	numberLocalesMu.Lock()
+	defer numberLocalesMu.Unlock()
+
+	numberLocales[strings.ToLower(strings.ReplaceAll(tag, "_", "-"))] = l
+}
+
+// lookupNumberLocale finds the locale for a tag like "de-AT", falling back to the
+// base language and then to English.
+func lookupNumberLocale(tag string) NumberLocale {
+	numberLocalesMu.RLock()
+	defer numberLocalesMu.RUnlock()
+
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if l, ok := numberLocales[tag]; ok {
+		return l
+	}
+	if base, _, ok := strings.Cut(tag, "-"); ok {
+		if l, ok := numberLocales[base]; ok {
+			return l
+		}
+	}
+
+	return numberLocales["en"]
+}
+
+// FormatMoney formats an amount in the minor units of currency (cents for USD), as
+// written in locale: FormatMoney(123456, "EUR", "de-DE") is "1.234,56 €" and
+// FormatMoney(-500, "USD", "en") is "-$5.00". Integer minor units avoid rounding
+// entirely; use FloatToIntMinorUnits with LookupCurrency(code).Digits to get them.
+func FormatMoney(minorUnits int64, currency, locale string) string {
+	c := LookupCurrency(currency)
+	l := lookupNumberLocale(locale)
+	if s, ok := l.Symbols[strings.ToUpper(currency)]; ok {
+		c.Symbol = s
+	}
+
+	digits := strconv.FormatUint(absUint64(minorUnits), 10)
+	if len(digits) <= c.Digits {
+		digits = strings.Repeat("0", c.Digits-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-c.Digits], digits[len(digits)-c.Digits:]
+
+	pattern := l.CurrencyPattern
+	if pattern == "" {
+		pattern = "¤#"
+	}
+	if minorUnits < 0 {
+		if l.NegativePattern != "" {
+			pattern = l.NegativePattern
+		} else {
+			pattern = "-" + pattern
+		}
+	}
+
+	return applyCurrencyPattern(pattern, c.Symbol, l.number(whole, frac))
+}
+
+// FormatNumber formats f with the given number of decimals, rounded half to even, in
+// locale: FormatNumber(1234567.891, 2, "en-IN") is "12,34,567.89". With negative
+// decimals f is written with as many as its shortest representation needs.
+func FormatNumber(f float64, decimals int, locale string) string {
+	if math.IsNaN(f) {
+		return "NaN"
+	}
+	if math.IsInf(f, 1) {
+		return "∞"
+	}
+	if math.IsInf(f, -1) {
+		return "-∞"
+	}
+
+	if decimals >= 0 {
+		f = RoundHalfEven(f, decimals)
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	out := lookupNumberLocale(locale).number(whole, frac)
+	if f < 0 && strings.Trim(whole+frac, "0") != "" {
+		out = "-" + out
+	}
+
+	return out
+}
+
+// number joins integer and fraction digits with the locale's separators.
+func (l NumberLocale) number(whole, frac string) string {
+	size := l.GroupSize
+	if size <= 0 {
+		size = 3
+	}
+	secondary := l.SecondaryGroupSize
+	if secondary <= 0 {
+		secondary = size
+	}
+	minGrouping := l.MinGrouping
+	if minGrouping <= 0 {
+		minGrouping = size + 1
+	}
+	decimal := l.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+
+	var sb strings.Builder
+	if len(whole) >= minGrouping && l.Group != "" {
+		var groups []string
+		end := len(whole)
+		n := size
+		for end > n {
+			groups = append(groups, whole[end-n:end])
+			end -= n
+			n = secondary
+		}
+		groups = append(groups, whole[:end])
+		for i := len(groups) - 1; i >= 0; i-- {
+			sb.WriteString(groups[i])
+			if i > 0 {
+				sb.WriteString(l.Group)
+			}
+		}
+	} else {
+		sb.WriteString(whole)
+	}
+	if frac != "" {
+		sb.WriteString(decimal)
+		sb.WriteString(frac)
+	}
+
+	return sb.String()
+}
+
+// applyCurrencyPattern fills in a pattern, spacing a letter symbol such as "CHF" from
+// the digits when the pattern puts them side by side.
+func applyCurrencyPattern(pattern, symbol, number string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "¤"):
+			next := pattern[i+len("¤"):]
+			sb.WriteString(symbol)
+			if r, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(r) && strings.HasPrefix(next, "#") {
+				sb.WriteString(nbsp)
+			}
+			i += len("¤")
+		case pattern[i] == '#':
+			sb.WriteString(number)
+			if r, _ := utf8.DecodeRuneInString(symbol); unicode.IsLetter(r) && strings.HasPrefix(pattern[i+1:], "¤") {
+				sb.WriteString(nbsp)
+			}
+			i++
+		default:
+			_, n := utf8.DecodeRuneInString(pattern[i:])
+			sb.WriteString(pattern[i : i+n])
+			i += n
+		}
+	}
+
+	return sb.String()
+}
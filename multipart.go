@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/upload"
+)
+
+var (
+	ErrNotMultipart = errors.New("request must be multipart/form-data")
+	ErrMissingFile  = errors.New("file is required")
+)
+
+// ReadMultipartForm parses a multipart/form-data body, keeping up to maxMemory bytes
+// of file parts in memory (32MB when zero) and spilling the rest to temporary files.
+// It is a no-op when the form has already been parsed.
+func ReadMultipartForm(r *http.Request, maxMemory int64) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+	if maxMemory <= 0 {
+		maxMemory = 32 << 20
+	}
+
+	err := r.ParseMultipartForm(maxMemory)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		return ErrNotMultipart
+	case strings.Contains(err.Error(), "http: request body too large"):
+		return fmt.Errorf("body must not be larger than the allowed size")
+	}
+
+	return fmt.Errorf("body contains a malformed multipart form: %w", err)
+}
+
+type UploadOptions struct {
+	upload.ValidateOptions
+	// MaxMemory is passed to ReadMultipartForm.
+	MaxMemory int64
+}
+
+// Upload is a validated file from a multipart form. It reads the file contents and
+// must be closed.
+type Upload struct {
+	multipart.File
+	// Filename is the client's filename made safe with upload.SanitizeFilename;
+	// OriginalFilename is as sent.
+	Filename         string
+	OriginalFilename string
+	Info             *upload.Info
+}
+
+// ReadFileUpload parses the form if needed and returns the file in field after
+// checking it with upload.Validate: its size, sniffed MIME type and extension against
+// the allowlists in opts. When the form has not been parsed yet and opts.MaxSize is
+// set, the body is capped a little above MaxSize so oversized uploads are cut off
+// early instead of being spooled to disk. It returns ErrMissingFile when the field
+// has no file, and the upload package errors for rejected files.
+func ReadFileUpload(r *http.Request, field string, opts UploadOptions) (*Upload, error) {
+	if r.MultipartForm == nil && opts.MaxSize > 0 {
+		// Leave room for the other parts and the multipart framing.
+		r.Body = http.MaxBytesReader(nil, r.Body, opts.MaxSize+1<<20)
+	}
+	if err := ReadMultipartForm(r, opts.MaxMemory); err != nil {
+		return nil, err
+	}
+
+	files := r.MultipartForm.File[field]
+	if len(files) == 0 {
+		return nil, ErrMissingFile
+	}
+	fh := files[0]
+
+	info, err := upload.ValidateUpload(fh, opts.ValidateOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Upload{
+		File:             f,
+		Filename:         upload.SanitizeFilename(fh.Filename),
+		OriginalFilename: fh.Filename,
+		Info:             info,
+	}, nil
+}
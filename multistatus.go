@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ItemResult is the outcome of one item of a bulk operation.
+type ItemResult struct {
+	// Index is the item's position in the request.
+	Index  int         `json:"index"`
+	ID     interface{} `json:"id,omitempty"`
+	Status int         `json:"status"`
+	Error  interface{} `json:"error,omitempty"`
+}
+
+// MultiStatus accumulates per-item results of a bulk create or update so partial
+// failures are reported consistently:
+//
+//	ms := helpers.NewMultiStatus()
+//	for i, in := range inputs {
+//		m, err := create(ctx, in)
+//		if err != nil {
+//			ms.Fail(i, nil, http.StatusUnprocessableEntity, err.Error())
+//			continue
+//		}
+//		ms.OK(i, m.ID, http.StatusCreated)
+//	}
+//	err := ms.Write(w)
+//
+// It is safe for concurrent use.
+type MultiStatus struct {
+	mu      sync.Mutex
+	results []ItemResult
+}
+
+func NewMultiStatus() *MultiStatus {
+	return &MultiStatus{}
+}
+
+// OK records a successful item.
+func (m *MultiStatus) OK(index int, id interface{}, status int) {
+	m.add(ItemResult{Index: index, ID: id, Status: status})
+}
+
+// Fail records a failed item. message can be a string or, for validation failures, a
+// map of field errors.
+func (m *MultiStatus) Fail(index int, id interface{}, status int, message interface{}) {
+	m.add(ItemResult{Index: index, ID: id, Status: status, Error: message})
+}
+
+func (m *MultiStatus) add(r ItemResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Keep results in request order even when items finish out of order.
+	i := len(m.results)
+	m.results = append(m.results, r)
+	for i > 0 && m.results[i-1].Index > r.Index {
+		m.results[i] = m.results[i-1]
+		i--
+	}
+	m.results[i] = r
+}
+
+// Results returns the recorded results ordered by index.
+func (m *MultiStatus) Results() []ItemResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]ItemResult(nil), m.results...)
+}
+
+// Counts returns the number of succeeded (2xx) and failed items.
+func (m *MultiStatus) Counts() (succeeded, failed int) {
+	for _, r := range m.Results() {
+		if r.Status >= 200 && r.Status < 300 {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	return succeeded, failed
+}
+
+// Status is the overall response status: the shared status when every item has the
+// same one, and 207 Multi-Status when they differ.
+func (m *MultiStatus) Status() int {
+	results := m.Results()
+	if len(results) == 0 {
+		return http.StatusOK
+	}
+
+	status := results[0].Status
+	for _, r := range results[1:] {
+		if r.Status != status {
+			return http.StatusMultiStatus
+		}
+	}
+
+	return status
+}
+
+// Envelope returns {"results": [...], "summary": {"total", "succeeded", "failed"}}.
+func (m *MultiStatus) Envelope() Envelope {
+	results := m.Results()
+	succeeded, failed := m.Counts()
+	if results == nil {
+		results = []ItemResult{}
+	}
+
+	return Envelope{
+		"results": results,
+		"summary": map[string]int{
+			"total":     len(results),
+			"succeeded": succeeded,
+			"failed":    failed,
+		},
+	}
+}
+
+// Write sends the envelope with Status.
+func (m *MultiStatus) Write(w http.ResponseWriter) error {
+	return WriteJSON(w, m.Status(), m.Envelope(), nil)
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hasahmad/go-helpers/mailer"
+)
+
+// EmailNotifier sends notifications with a Mailer. Each notification type maps to a
+// mailer template file, by default "<type>.tmpl".
+type EmailNotifier struct {
+	Mailer    *mailer.Mailer
+	Templates map[string]string
+}
+
+func (e *EmailNotifier) Channel() string { return "email" }
+
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.Recipient.Email == "" {
+		return ErrNoDestination
+	}
+
+	file, ok := e.Templates[n.Type]
+	if !ok {
+		file = n.Type + ".tmpl"
+	}
+
+	msg, err := e.Mailer.Render(n.Recipient.Email, file, n.Data)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNoTemplate, n.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.Mailer.SendMessage(ctx, msg)
+}
+
+// SMSNotifier sends text messages through a Twilio-compatible HTTP API.
+type SMSNotifier struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	// BaseURL defaults to https://api.twilio.com.
+	BaseURL   string
+	Templates Templates
+	Client    *http.Client
+}
+
+func (s *SMSNotifier) Channel() string { return "sms" }
+
+func (s *SMSNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.Recipient.Phone == "" {
+		return ErrNoDestination
+	}
+
+	body, err := s.Templates.render(n.Type, n.Data)
+	if err != nil {
+		return err
+	}
+
+	base := s.BaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	endpoint := strings.TrimRight(base, "/") + "/2010-04-01/Accounts/" + url.PathEscape(s.AccountSID) + "/Messages.json"
+	form := url.Values{"To": {n.Recipient.Phone}, "From": {s.From}, "Body": {body}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+
+	return doRequest(s.Client, req)
+}
+
+// PushTemplate is the title and body template source for one notification type.
+type PushTemplate struct {
+	Title string
+	Body  string
+}
+
+// PushNotifier posts push notifications as JSON to a push gateway, one request per
+// device token:
+//
+//	{"to": "<token>", "title": "...", "body": "...", "data": {...}}
+type PushNotifier struct {
+	Endpoint string
+	// Authorization is sent verbatim in the Authorization header, e.g. "Bearer ...".
+	Authorization string
+	Templates     map[string]PushTemplate
+	Client        *http.Client
+}
+
+func (p *PushNotifier) Channel() string { return "push" }
+
+func (p *PushNotifier) Notify(ctx context.Context, n Notification) error {
+	if len(n.Recipient.DeviceTokens) == 0 {
+		return ErrNoDestination
+	}
+
+	tmpl, ok := p.Templates[n.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoTemplate, n.Type)
+	}
+	title, err := Templates{"title": tmpl.Title}.render("title", n.Data)
+	if err != nil {
+		return err
+	}
+	body, err := Templates{"body": tmpl.Body}.render("body", n.Data)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range n.Recipient.DeviceTokens {
+		payload, err := json.Marshal(map[string]interface{}{
+			"to":    token,
+			"title": title,
+			"body":  body,
+			"data":  n.Data,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if p.Authorization != "" {
+			req.Header.Set("Authorization", p.Authorization)
+		}
+
+		if err := doRequest(p.Client, req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notify: %s responded %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
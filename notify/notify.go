@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+var (
+	ErrNoTemplate    = errors.New("notify: no template for notification type")
+	ErrNoDestination = errors.New("notify: recipient has no address for channel")
+)
+
+// Recipient holds the per-channel addresses of whoever is being notified.
+type Recipient struct {
+	ID           string
+	Email        string
+	Phone        string
+	DeviceTokens []string
+}
+
+// Notification is a channel-agnostic message. Type selects the template each channel
+// renders, e.g. "order_shipped".
+type Notification struct {
+	Type      string
+	Recipient Recipient
+	Data      map[string]interface{}
+}
+
+// Notifier delivers notifications over one channel.
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// PreferenceFunc reports whether recipient wants notifications of type over channel.
+type PreferenceFunc func(ctx context.Context, recipient Recipient, notificationType, channel string) bool
+
+// Errors collects per-channel delivery failures.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for channel, err := range e {
+		parts = append(parts, channel+": "+err.Error())
+	}
+
+	return "notify: " + strings.Join(parts, "; ")
+}
+
+type DispatcherOptions struct {
+	Notifiers []Notifier
+	// Preferences filters channels per recipient. Nil sends on every channel.
+	Preferences PreferenceFunc
+}
+
+// Dispatcher fans a notification out to every channel the recipient accepts.
+type Dispatcher struct {
+	opts DispatcherOptions
+}
+
+func NewDispatcher(opts DispatcherOptions) *Dispatcher {
+	return &Dispatcher{opts: opts}
+}
+
+// Dispatch sends n on all accepted channels concurrently. Channels without a template
+// for n.Type or an address for the recipient are skipped. Other failures are returned
+// as Errors keyed by channel; successful channels are not affected by failing ones.
+func (d *Dispatcher) Dispatch(ctx context.Context, n Notification) error {
+	var (
+		mu   sync.Mutex
+		errs = Errors{}
+		wg   sync.WaitGroup
+	)
+
+	for _, notifier := range d.opts.Notifiers {
+		channel := notifier.Channel()
+		if d.opts.Preferences != nil && !d.opts.Preferences(ctx, n.Recipient, n.Type, channel) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(notifier Notifier) {
+			defer wg.Done()
+
+			err := notifier.Notify(ctx, n)
+			if err == nil || errors.Is(err, ErrNoTemplate) || errors.Is(err, ErrNoDestination) {
+				return
+			}
+			mu.Lock()
+			errs[channel] = err
+			mu.Unlock()
+		}(notifier)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Templates maps notification types to text/template sources for short channels such
+// as SMS and push.
+type Templates map[string]string
+
+func (t Templates) render(name string, data interface{}) (string, error) {
+	src, ok := t[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoTemplate, name)
+	}
+
+	tmpl, err := template.New(name).Parse(src)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,286 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/ratelimit"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeveritySuccess  Severity = "success"
+	SeverityWarning  Severity = "warning"
+	SeverityError    Severity = "error"
+	SeverityCritical Severity = "critical"
+)
+
+// color returns the hex RGB color used for the severity.
+func (s Severity) color() int {
+	switch s {
+	case SeveritySuccess:
+		return 0x2eb67d
+	case SeverityWarning:
+		return 0xecb22e
+	case SeverityError:
+		return 0xe01e5a
+	case SeverityCritical:
+		return 0x8b0000
+	}
+
+	return 0x36c5f0
+}
+
+// Field is a name/value pair rendered as a table cell.
+type Field struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// WebhookMessage is a formatted ops message for Slack or Discord.
+type WebhookMessage struct {
+	Title    string
+	Text     string
+	URL      string
+	Severity Severity
+	Fields   []Field
+	Footer   string
+}
+
+type WebhookOptions struct {
+	URL    string
+	Client *http.Client
+	// RatePerSecond caps messages sent to the webhook. Defaults to 1, which is what
+	// Slack allows per incoming webhook.
+	RatePerSecond float64
+	Burst         int
+	// Retries is the number of extra attempts on 429 and 5xx responses. Defaults to 3.
+	Retries int
+	// Templates render Notification types into the message text, for use through the
+	// Notifier interface. The notification Data is also added as fields.
+	Templates Templates
+}
+
+type webhook struct {
+	opts    WebhookOptions
+	once    sync.Once
+	limiter *ratelimit.Limiter
+}
+
+func (w *webhook) post(ctx context.Context, payload interface{}) error {
+	w.once.Do(func() {
+		if w.opts.RatePerSecond <= 0 {
+			w.opts.RatePerSecond = 1
+		}
+		if w.opts.Burst <= 0 {
+			w.opts.Burst = 1
+		}
+		if w.opts.Retries == 0 {
+			w.opts.Retries = 3
+		}
+		if w.opts.Client == nil {
+			w.opts.Client = &http.Client{Timeout: 10 * time.Second}
+		}
+		w.limiter = ratelimit.New(w.opts.RatePerSecond, w.opts.Burst)
+	})
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := w.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := w.opts.Client.Do(req)
+		var status int
+		var wait time.Duration
+		if err == nil {
+			status = resp.StatusCode
+			if secs, perr := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); perr == nil {
+				wait = time.Duration(secs * float64(time.Second))
+			}
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+			if status < 300 {
+				return nil
+			}
+			err = fmt.Errorf("notify: webhook responded %d: %s", status, bytes.TrimSpace(msg))
+		}
+
+		retryable := status == 0 || status == http.StatusTooManyRequests || status >= 500
+		if !retryable || attempt >= w.opts.Retries {
+			return err
+		}
+
+		if wait <= 0 {
+			wait = time.Duration(1<<attempt) * time.Second
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func messageFromNotification(t Templates, n Notification) (WebhookMessage, error) {
+	text, err := t.render(n.Type, n.Data)
+	if err != nil {
+		return WebhookMessage{}, err
+	}
+
+	msg := WebhookMessage{Title: n.Type, Text: text, Severity: SeverityInfo}
+	if s, ok := n.Data["severity"].(string); ok {
+		msg.Severity = Severity(s)
+	}
+	keys := make([]string, 0, len(n.Data))
+	for k := range n.Data {
+		if k != "severity" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		msg.Fields = append(msg.Fields, Field{Name: k, Value: fmt.Sprint(n.Data[k]), Inline: true})
+	}
+
+	return msg, nil
+}
+
+// Slack posts messages to a Slack incoming webhook as an attachment with the severity
+// color, a header, a text section and a two-column fields table.
+type Slack struct {
+	webhook
+}
+
+func NewSlack(opts WebhookOptions) *Slack {
+	return &Slack{webhook{opts: opts}}
+}
+
+func (s *Slack) Channel() string { return "slack" }
+
+func (s *Slack) Notify(ctx context.Context, n Notification) error {
+	msg, err := messageFromNotification(s.opts.Templates, n)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(ctx, msg)
+}
+
+func (s *Slack) Send(ctx context.Context, msg WebhookMessage) error {
+	var blocks []map[string]interface{}
+	if msg.Title != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": msg.Title},
+		})
+	}
+	if msg.Text != "" {
+		text := msg.Text
+		if msg.URL != "" {
+			text += "\n<" + msg.URL + ">"
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+		})
+	}
+	// Slack allows at most ten fields per section.
+	for i := 0; i < len(msg.Fields); i += 10 {
+		end := i + 10
+		if end > len(msg.Fields) {
+			end = len(msg.Fields)
+		}
+		var fields []map[string]interface{}
+		for _, f := range msg.Fields[i:end] {
+			fields = append(fields, map[string]interface{}{"type": "mrkdwn", "text": "*" + f.Name + "*\n" + f.Value})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	if msg.Footer != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]interface{}{{"type": "mrkdwn", "text": msg.Footer}},
+		})
+	}
+
+	return s.post(ctx, map[string]interface{}{
+		"text": msg.Title,
+		"attachments": []map[string]interface{}{{
+			"color":  fmt.Sprintf("#%06x", msg.Severity.color()),
+			"blocks": blocks,
+		}},
+	})
+}
+
+// Discord posts messages to a Discord webhook as an embed.
+type Discord struct {
+	webhook
+}
+
+func NewDiscord(opts WebhookOptions) *Discord {
+	return &Discord{webhook{opts: opts}}
+}
+
+func (d *Discord) Channel() string { return "discord" }
+
+func (d *Discord) Notify(ctx context.Context, n Notification) error {
+	msg, err := messageFromNotification(d.opts.Templates, n)
+	if err != nil {
+		return err
+	}
+
+	return d.Send(ctx, msg)
+}
+
+func (d *Discord) Send(ctx context.Context, msg WebhookMessage) error {
+	embed := map[string]interface{}{
+		"title":       msg.Title,
+		"description": msg.Text,
+		"color":       msg.Severity.color(),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+	}
+	if msg.URL != "" {
+		embed["url"] = msg.URL
+	}
+	if msg.Footer != "" {
+		embed["footer"] = map[string]interface{}{"text": msg.Footer}
+	}
+
+	// Discord allows at most 25 fields per embed.
+	var fields []map[string]interface{}
+	for i, f := range msg.Fields {
+		if i == 25 {
+			break
+		}
+		fields = append(fields, map[string]interface{}{"name": f.Name, "value": f.Value, "inline": f.Inline})
+	}
+	if len(fields) > 0 {
+		embed["fields"] = fields
+	}
+
+	return d.post(ctx, map[string]interface{}{"embeds": []interface{}{embed}})
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrOverflow      = errors.New("value out of range")
+	ErrPrecisionLoss = errors.New("value cannot be represented without losing precision")
+)
+
+// Int64ToInt32 converts v, failing with ErrOverflow instead of wrapping.
+func Int64ToInt32(v int64) (int32, error) {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return 0, ErrOverflow
+	}
+
+	return int32(v), nil
+}
+
+// Int64ToInt converts v, failing with ErrOverflow on 32-bit platforms when v does not
+// fit.
+func Int64ToInt(v int64) (int, error) {
+	if int64(int(v)) != v {
+		return 0, ErrOverflow
+	}
+
+	return int(v), nil
+}
+
+// Uint64ToInt64 converts v, failing with ErrOverflow above math.MaxInt64.
+func Uint64ToInt64(v uint64) (int64, error) {
+	if v > math.MaxInt64 {
+		return 0, ErrOverflow
+	}
+
+	return int64(v), nil
+}
+
+// FloatToIntMinorUnits converts an amount such as 19.99 to minor units (1999 for two
+// decimals). The float is interpreted as its shortest decimal representation, so
+// 19.99 is exact rather than 1998.9999. It fails with ErrPrecisionLoss when the
+// amount has more decimals than given, and ErrOverflow when it does not fit in an
+// int64.
+func FloatToIntMinorUnits(f float64, decimals int) (int64, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrOverflow
+	}
+
+	neg, whole, frac := splitDecimal(f)
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return 0, ErrPrecisionLoss
+	}
+
+	return digitsToInt(neg, whole+frac+strings.Repeat("0", decimals-len(frac)))
+}
+
+// RoundHalfEven rounds f to the given number of decimals using banker's rounding,
+// where ties go to the even neighbour (2.5 → 2, 3.5 → 4), which avoids the upward
+// bias of half-up rounding when summing many rounded amounts. Like
+// FloatToIntMinorUnits it works on the shortest decimal representation of f, so
+// 2.675 is a tie rather than 2.67499999.
+func RoundHalfEven(f float64, decimals int) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) || decimals < 0 {
+		return f
+	}
+
+	neg, whole, frac := splitDecimal(f)
+	if len(frac) <= decimals {
+		return f
+	}
+
+	keep, rest := frac[:decimals], frac[decimals:]
+	digits := []byte(whole + keep)
+	if roundUpHalfEven(rest, digits[len(digits)-1]) {
+		digits = incrementDigits(digits)
+	}
+
+	s := string(digits)
+	if decimals > 0 {
+		s = s[:len(s)-decimals] + "." + s[len(s)-decimals:]
+	}
+	if neg {
+		s = "-" + s
+	}
+
+	out, _ := strconv.ParseFloat(s, 64)
+	return out
+}
+
+// DivRoundHalfEven returns a/b rounded half to even, for integer currency math such
+// as splitting an amount or applying a rate in minor units. It panics if b is zero,
+// like integer division.
+func DivRoundHalfEven(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r == 0 {
+		return q
+	}
+
+	// Compare 2|r| with |b| without overflowing.
+	absR, absB := absUint64(r), absUint64(b)
+	sign := int64(1)
+	if (a < 0) != (b < 0) {
+		sign = -1
+	}
+
+	switch {
+	case absR > absB-absR:
+		return q + sign
+	case absR == absB-absR && q%2 != 0:
+		return q + sign
+	}
+
+	return q
+}
+
+func absUint64(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+
+	return uint64(v)
+}
+
+// splitDecimal returns the sign, integer digits and fractional digits of f's shortest
+// decimal representation.
+func splitDecimal(f float64) (bool, string, string) {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+
+	return neg, whole, frac
+}
+
+func roundUpHalfEven(rest string, last byte) bool {
+	switch {
+	case rest[0] > '5':
+		return true
+	case rest[0] < '5':
+		return false
+	case strings.TrimRight(rest[1:], "0") != "":
+		return true
+	}
+
+	// Exactly half: round to the even neighbour.
+	return (last-'0')%2 == 1
+}
+
+func incrementDigits(d []byte) []byte {
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i] < '9' {
+			d[i]++
+			return d
+		}
+		d[i] = '0'
+	}
+
+	return append([]byte{'1'}, d...)
+}
+
+func digitsToInt(neg bool, digits string) (int64, error) {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return 0, nil
+	}
+	if neg {
+		digits = "-" + digits
+	}
+
+	v, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+
+	return v, nil
+}
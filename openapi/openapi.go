@@ -0,0 +1,257 @@
+// Package openapi builds an OpenAPI 3 document from registered routes and the Go types
+// they read and write, so the spec is generated from the code instead of drifting
+// away from it.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Operation describes one route.
+type Operation struct {
+	Method      string
+	Path        string // chi pattern, e.g. /v1/movies/{id}
+	Summary     string
+	Description string
+	Tags        []string
+	// Query is a struct whose `query:"name"` fields are query parameters. Their
+	// `openapi` tags are honoured like body fields.
+	Query interface{}
+	// Request is the JSON request body type, as read with ReadJSON.
+	Request interface{}
+	// Response is the JSON response body for Status (default 200).
+	Response interface{}
+	// ResponseKey wraps Response in an envelope object, e.g. "movie" for
+	// {"movie": {...}}.
+	ResponseKey string
+	Status      int
+	// Errors lists additional statuses returned, documented with the standard
+	// {"error": ...} envelope.
+	Errors []int
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Spec collects operations and renders them as an OpenAPI 3.0 document.
+type Spec struct {
+	Info    Info
+	Servers []string
+
+	mu             sync.Mutex
+	ops            []Operation
+	components     map[string]*Schema
+	componentTypes map[string]reflect.Type
+	overrides      map[reflect.Type]Schema
+}
+
+func New(title, version string) *Spec {
+	return &Spec{Info: Info{Title: title, Version: version}}
+}
+
+// Add registers an operation.
+func (s *Spec) Add(op Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if op.Status == 0 {
+		op.Status = http.StatusOK
+	}
+	s.ops = append(s.ops, op)
+}
+
+// Route registers op with the spec and h with the router in one call, so the two
+// cannot get out of step.
+func (s *Spec) Route(r chi.Router, op Operation, h http.HandlerFunc) {
+	s.Add(op)
+	r.Method(op.Method, op.Path, h)
+}
+
+// Handler serves the document as JSON, typically mounted at /openapi.json.
+func (s *Spec) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		js, err := s.MarshalJSON()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+var pathParamRX = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// MarshalJSON renders the document.
+func (s *Spec) MarshalJSON() ([]byte, error) {
+	return json.MarshalIndent(s.Document(), "", "  ")
+}
+
+// Document returns the OpenAPI document as a JSON-ready value.
+func (s *Spec) Document() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.components = map[string]*Schema{}
+	s.componentTypes = map[string]reflect.Type{}
+
+	paths := map[string]map[string]interface{}{}
+	for _, op := range s.ops {
+		// chi regexp params such as {id:[0-9]+} become plain {id}.
+		path := pathParamRX.ReplaceAllString(op.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(op.Method)] = s.operation(op)
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    s.Info,
+		"paths":   paths,
+	}
+	if len(s.Servers) > 0 {
+		servers := make([]map[string]string, len(s.Servers))
+		for i, u := range s.Servers {
+			servers[i] = map[string]string{"url": u}
+		}
+		doc["servers"] = servers
+	}
+	if len(s.components) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": s.components}
+	}
+
+	return doc
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+func (s *Spec) operation(op Operation) map[string]interface{} {
+	out := map[string]interface{}{}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if len(op.Tags) > 0 {
+		out["tags"] = op.Tags
+	}
+
+	var params []parameter
+	for _, m := range pathParamRX.FindAllStringSubmatch(op.Path, -1) {
+		params = append(params, parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if op.Query != nil {
+		params = append(params, s.queryParams(reflect.TypeOf(op.Query))...)
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Request != nil {
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(s.schemaFor(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	responses := map[string]interface{}{}
+	ok := map[string]interface{}{"description": http.StatusText(op.Status)}
+	if op.Response != nil {
+		schema := s.schemaFor(reflect.TypeOf(op.Response))
+		if op.ResponseKey != "" {
+			schema = &Schema{Type: "object", Properties: map[string]*Schema{op.ResponseKey: schema}, Required: []string{op.ResponseKey}}
+		}
+		ok["content"] = jsonContent(schema)
+	}
+	responses[strconv.Itoa(op.Status)] = ok
+
+	errs := append([]int(nil), op.Errors...)
+	sort.Ints(errs)
+	for _, status := range errs {
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     jsonContent(errorSchema(status)),
+		}
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+// errorSchema matches the {"error": ...} envelope: validation failures carry a map of
+// field messages, everything else a message string.
+func errorSchema(status int) *Schema {
+	msg := &Schema{Type: "string"}
+	if status == http.StatusUnprocessableEntity {
+		msg = &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}
+	}
+
+	return &Schema{Type: "object", Properties: map[string]*Schema{"error": msg}, Required: []string{"error"}}
+}
+
+func jsonContent(schema *Schema) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func (s *Spec) queryParams(t reflect.Type) []parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("query") == "" {
+			params = append(params, s.queryParams(f.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("query"), ",")
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+
+		tag := parseTag(f.Tag.Get("openapi"))
+		schema := tag.apply(s.schemaFor(f.Type))
+		schema.Description = ""
+		p := parameter{
+			Name:        name,
+			In:          "query",
+			Required:    tag.required,
+			Description: tag.description,
+			Schema:      schema,
+		}
+		if schema.Type == "array" {
+			// Lists are read as comma separated values.
+			explode := false
+			p.Style, p.Explode = "form", &explode
+		}
+		params = append(params, p)
+	}
+
+	return params
+}
@@ -0,0 +1,343 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is an OpenAPI 3 schema object. Only the keywords generated here are modelled.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	nullUUIDType      = reflect.TypeOf(uuid.NullUUID{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// Override documents every value of v's type with schema instead of deriving one by
+// reflection, for types with their own JSON encoding:
+//
+//	spec.Override(Money{}, openapi.Schema{Type: "string", Example: "12.50 EUR"})
+func (s *Spec) Override(v interface{}, schema Schema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.overrides == nil {
+		s.overrides = map[reflect.Type]Schema{}
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s.overrides[t] = schema
+}
+
+// marshalsItself reports whether json encodes t with its MarshalJSON or MarshalText
+// method, on the value or its pointer.
+func marshalsItself(t reflect.Type) bool {
+	for _, typ := range []reflect.Type{t, reflect.PointerTo(t)} {
+		if typ.Implements(jsonMarshalerType) || typ.Implements(textMarshalerType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schemaFor returns the schema of t, registering named struct types as components
+// and referencing them. Types that marshal themselves are documented as strings
+// unless overridden with Override.
+func (s *Spec) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if schema, ok := s.overrides[t]; ok {
+		return &schema
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case nullUUIDType:
+		return &Schema{Type: "string", Format: "uuid", Nullable: true}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if marshalsItself(t) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := s.componentName(t)
+		if _, ok := s.components[name]; !ok {
+			// Register first so recursive types terminate.
+			s.components[name] = &Schema{}
+			*s.components[name] = *s.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &Schema{}
+}
+
+func (s *Spec) componentName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		// Generic instantiations such as Page[main.Movie] are not valid component names.
+		name = name[:i] + "_" + strings.NewReplacer("[", "", "]", "", ".", "_", "/", "_", ",", "_", " ", "").Replace(name[i:])
+	}
+	if other, ok := s.componentTypes[name]; ok && other != t {
+		name = strings.ReplaceAll(t.PkgPath(), "/", "_") + "_" + name
+	}
+	s.componentTypes[name] = t
+
+	return name
+}
+
+func (s *Spec) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *Spec) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(schema, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := s.schemaFor(f.Type)
+		if opts.asString {
+			prop = &Schema{Type: "string", Format: prop.Format}
+		}
+		tag := parseTag(f.Tag.Get("openapi"))
+		prop = tag.apply(prop)
+		if f.Type.Kind() == reflect.Pointer && prop.Ref == "" {
+			prop.Nullable = true
+		}
+
+		schema.Properties[name] = prop
+		if tag.required || (!opts.omitempty && f.Type.Kind() != reflect.Pointer && !tag.optional) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+type jsonOpts struct {
+	omitempty bool
+	asString  bool
+}
+
+func jsonName(f reflect.StructField) (string, jsonOpts) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "-", jsonOpts{}
+	}
+
+	name, rest, _ := strings.Cut(tag, ",")
+	var opts jsonOpts
+	for _, o := range strings.Split(rest, ",") {
+		switch o {
+		case "omitempty":
+			opts.omitempty = true
+		case "string":
+			opts.asString = true
+		}
+	}
+
+	return name, opts
+}
+
+// fieldTag holds the options of an `openapi:"..."` struct tag, e.g.
+// `openapi:"required,description=Release year,min=1888,max=2100,example=1942"`.
+type fieldTag struct {
+	required    bool
+	optional    bool
+	description string
+	format      string
+	example     string
+	enum        []string
+	min, max    *float64
+}
+
+func parseTag(tag string) fieldTag {
+	var ft fieldTag
+	if tag == "" {
+		return ft
+	}
+
+	for _, part := range splitTag(tag) {
+		key, val, _ := strings.Cut(part, "=")
+		switch key {
+		case "required":
+			ft.required = true
+		case "optional":
+			ft.optional = true
+		case "description":
+			ft.description = val
+		case "format":
+			ft.format = val
+		case "example":
+			ft.example = val
+		case "enum":
+			ft.enum = strings.Split(val, "|")
+		case "min":
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				ft.min = &f
+			}
+		case "max":
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				ft.max = &f
+			}
+		}
+	}
+
+	return ft
+}
+
+// splitTag splits on commas, allowing "\," inside descriptions.
+func splitTag(tag string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			cur.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(tag[i])
+		}
+	}
+
+	return append(parts, cur.String())
+}
+
+func (ft fieldTag) apply(prop *Schema) *Schema {
+	// OpenAPI 3.0 ignores keywords next to $ref, so references are left alone.
+	if prop.Ref != "" {
+		return prop
+	}
+
+	if ft.description != "" {
+		prop.Description = ft.description
+	}
+	if ft.format != "" {
+		prop.Format = ft.format
+	}
+	if ft.example != "" {
+		prop.Example = typedValue(prop.Type, ft.example)
+	}
+	for _, e := range ft.enum {
+		prop.Enum = append(prop.Enum, typedValue(prop.Type, e))
+	}
+
+	if prop.Type == "string" {
+		if ft.min != nil {
+			n := int(*ft.min)
+			prop.MinLength = &n
+		}
+		if ft.max != nil {
+			n := int(*ft.max)
+			prop.MaxLength = &n
+		}
+	} else {
+		prop.Minimum, prop.Maximum = ft.min, ft.max
+	}
+
+	return prop
+}
+
+func typedValue(typ, s string) interface{} {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return s
+}
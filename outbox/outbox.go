@@ -0,0 +1,108 @@
+// Package outbox implements the transactional outbox pattern for PostgreSQL: events
+// are inserted in the same transaction as the business data they describe, and a
+// Relay publishes them to a Sink afterwards, so an event is never lost when the
+// transaction commits and never sent when it rolls back.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTable is the table used when no other is configured.
+const DefaultTable = "outbox"
+
+// Schema creates DefaultTable. Use it in a migration, replacing the table name when
+// using another one.
+const Schema = `CREATE TABLE IF NOT EXISTS outbox (
+	id uuid PRIMARY KEY,
+	topic text NOT NULL,
+	key text NOT NULL DEFAULT '',
+	payload jsonb NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text NOT NULL DEFAULT '',
+	published_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL;`
+
+var (
+	ErrEmptyTopic = errors.New("outbox: topic must not be empty")
+)
+
+// Execer is satisfied by *sql.Tx, and by *sql.DB for events that need not be atomic
+// with other writes.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Event is a message waiting in, or read from, the outbox.
+type Event struct {
+	// ID is unique per event and stays the same across delivery attempts, so sinks
+	// can use it to discard duplicates.
+	ID    string
+	Topic string
+	// Key groups related events, e.g. the id of the aggregate they describe. It is
+	// passed to the sink, which may use it for partitioning.
+	Key       string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	// Attempts counts the failed deliveries so far.
+	Attempts int
+}
+
+// Write inserts an event into DefaultTable using tx, normally the transaction that
+// writes the data the event describes:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer tx.Rollback()
+//
+//	if _, err := tx.ExecContext(ctx, "INSERT INTO orders ...", ...); err != nil {
+//		return err
+//	}
+//	if _, err := outbox.Write(ctx, tx, "order.created", order.ID, order); err != nil {
+//		return err
+//	}
+//	return tx.Commit()
+//
+// payload is marshalled to JSON. The event is returned with its generated ID.
+func Write(ctx context.Context, tx Execer, topic, key string, payload interface{}) (Event, error) {
+	return WriteTable(ctx, tx, DefaultTable, topic, key, payload)
+}
+
+// WriteTable is Write into the given table, which is written into SQL as is.
+func WriteTable(ctx context.Context, tx Execer, table, topic, key string, payload interface{}) (Event, error) {
+	if topic == "" {
+		return Event{}, ErrEmptyTopic
+	}
+
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("outbox: marshal payload: %w", err)
+	}
+
+	e := Event{
+		ID:        uuid.NewString(),
+		Topic:     topic,
+		Key:       key,
+		Payload:   js,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	query := "INSERT INTO " + table + " (id, topic, key, payload, created_at, next_attempt_at) VALUES ($1, $2, $3, $4, $5, $5)"
+	if _, err := tx.ExecContext(ctx, query, e.ID, e.Topic, e.Key, []byte(e.Payload), e.CreatedAt); err != nil {
+		return Event{}, err
+	}
+
+	return e, nil
+}
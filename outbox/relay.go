@@ -0,0 +1,238 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hasahmad/go-helpers/clock"
+	"github.com/hasahmad/go-helpers/timeutil"
+)
+
+// Sink delivers events to the outside world, e.g. a webhook or a message queue. An
+// event may be delivered more than once, so Publish should be idempotent on Event.ID.
+type Sink interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, e Event) error
+
+func (f SinkFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+type RelayOptions struct {
+	Sink Sink
+	// Table defaults to DefaultTable. It is written into SQL as is.
+	Table string
+	// BatchSize is the number of events claimed per run. Defaults to 100.
+	BatchSize int
+	// MaxAttempts is the number of deliveries tried before an event is left in the
+	// table as dead, with its last error, for a person to look at. Defaults to 10.
+	MaxAttempts int
+	// Backoff returns the delay before retrying an event that failed attempts times.
+	// Defaults to doubling from one second, capped at one hour.
+	Backoff func(attempts int) time.Duration
+	// Lease is how long a claimed batch is hidden from other relays while it is
+	// published. An event still pending when its lease runs out, e.g. because the
+	// relay crashed, is claimed again. Defaults to five minutes; it should comfortably
+	// exceed the time the sink takes for a whole batch.
+	Lease time.Duration
+	// Interval is the delay between polls in Run. Defaults to one second.
+	Interval time.Duration
+	// Jitter is passed to timeutil.Schedule.
+	Jitter float64
+	// OnError receives failed deliveries and database errors; e is the zero Event for
+	// the latter.
+	OnError func(e Event, err error)
+	// Clock is the time source. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// Relay publishes pending outbox events to a sink. Several relays, in one process or
+// many, can share a table: each run claims a batch by moving its next_attempt_at past
+// the Lease in a single statement using FOR UPDATE SKIP LOCKED, so an event is handed
+// to one relay at a time and no transaction stays open while the sink is called.
+//
+// Delivery is at least once. Each event is marked published on its own as soon as
+// the sink accepts it, so a crash or database error redelivers, with the same ID, only
+// the events whose outcome was not yet recorded; sinks that drop IDs they have seen
+// get exactly-once processing.
+type Relay struct {
+	db   *sql.DB
+	opts RelayOptions
+}
+
+func NewRelay(db *sql.DB, opts RelayOptions) *Relay {
+	if opts.Table == "" {
+		opts.Table = DefaultTable
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	return &Relay{db: db, opts: opts}
+}
+
+func defaultBackoff(attempts int) time.Duration {
+	if attempts > 12 {
+		return time.Hour
+	}
+	d := time.Duration(1<<(attempts-1)) * time.Second
+	if d > time.Hour {
+		return time.Hour
+	}
+
+	return d
+}
+
+// Run polls the outbox every Interval until ctx is done, going straight on to the
+// next batch while batches come back full.
+func (rl *Relay) Run(ctx context.Context) {
+	timeutil.Schedule(ctx, timeutil.ScheduleOptions{
+		Interval:  rl.opts.Interval,
+		Jitter:    rl.opts.Jitter,
+		Immediate: true,
+		Clock:     rl.opts.Clock,
+		OnPanic: func(recovered interface{}) {
+			rl.reportError(Event{}, timeutil.PanicError(recovered))
+		},
+	}, func(ctx context.Context) {
+		for ctx.Err() == nil {
+			n, err := rl.RunOnce(ctx)
+			if err != nil {
+				rl.reportError(Event{}, err)
+				return
+			}
+			if n < rl.opts.BatchSize {
+				return
+			}
+		}
+	})
+}
+
+// RunOnce claims one batch of due events, publishes them in order and records the
+// outcome of each. It returns the number of events claimed.
+func (rl *Relay) RunOnce(ctx context.Context) (int, error) {
+	now := rl.opts.Clock.Now().UTC()
+	events, err := rl.claim(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range events {
+		if err := rl.opts.Sink.Publish(ctx, e); err != nil {
+			rl.reportError(e, err)
+			if err := rl.markFailed(ctx, e, err, rl.opts.Clock.Now().UTC()); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := rl.markPublished(ctx, e, rl.opts.Clock.Now().UTC()); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+func (rl *Relay) claim(ctx context.Context, now time.Time) ([]Event, error) {
+	query := "UPDATE " + rl.opts.Table + " SET next_attempt_at = $1 WHERE id IN (" +
+		"SELECT id FROM " + rl.opts.Table +
+		" WHERE published_at IS NULL AND attempts < $2 AND next_attempt_at <= $3" +
+		" ORDER BY created_at, id LIMIT $4 FOR UPDATE SKIP LOCKED)" +
+		" RETURNING id, topic, key, payload, created_at, attempts"
+
+	rows, err := rl.db.QueryContext(ctx, query, now.Add(rl.opts.Lease), rl.opts.MaxAttempts, now, rl.opts.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Topic, &e.Key, &payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		e.Payload = json.RawMessage(payload)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+func (rl *Relay) markPublished(ctx context.Context, e Event, now time.Time) error {
+	_, err := rl.db.ExecContext(ctx, "UPDATE "+rl.opts.Table+" SET published_at = $1 WHERE id = $2", now, e.ID)
+	return err
+}
+
+func (rl *Relay) markFailed(ctx context.Context, e Event, cause error, now time.Time) error {
+	attempts := e.Attempts + 1
+	next := now.Add(rl.opts.Backoff(attempts))
+	msg := truncate(cause.Error(), 1000)
+
+	_, err := rl.db.ExecContext(ctx,
+		"UPDATE "+rl.opts.Table+" SET attempts = $1, next_attempt_at = $2, last_error = $3 WHERE id = $4",
+		attempts, next, msg, e.ID)
+	return err
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence, which
+// Postgres would reject as invalid text.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+func (rl *Relay) reportError(e Event, err error) {
+	if rl.opts.OnError != nil {
+		rl.opts.OnError(e, err)
+	}
+}
+
+// Purge deletes events published before olderThan and returns how many were removed.
+// Dead events are kept.
+func (rl *Relay) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	res, err := rl.db.ExecContext(ctx, "DELETE FROM "+rl.opts.Table+" WHERE published_at < $1", olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type WebhookOptions struct {
+	URL    string
+	Client *http.Client
+	// Header is added to every request, e.g. for an Authorization header.
+	Header http.Header
+}
+
+// WebhookSink POSTs each event's payload to a URL. The event ID is sent as the
+// Idempotency-Key header, alongside X-Outbox-Topic and X-Outbox-Key, and any 2xx
+// response counts as delivered. Retries are left to the Relay.
+func WebhookSink(opts WebhookOptions) Sink {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return SinkFunc(func(ctx context.Context, e Event) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(e.Payload))
+		if err != nil {
+			return err
+		}
+		for k, v := range opts.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", e.ID)
+		req.Header.Set("X-Outbox-Topic", e.Topic)
+		if e.Key != "" {
+			req.Header.Set("X-Outbox-Key", e.Key)
+		}
+
+		resp, err := opts.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return fmt.Errorf("outbox: webhook responded %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+		}
+		io.Copy(io.Discard, resp.Body)
+
+		return nil
+	})
+}
+
+// ChanSink sends events to ch, for handing them to an in-process queue consumer. It
+// blocks while ch is full and gives up when ctx is done.
+func ChanSink(ch chan<- Event) Sink {
+	return SinkFunc(func(ctx context.Context, e Event) error {
+		select {
+		case ch <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ParamReader looks up URL path parameters for the router in use. ok is false when
+// the matched route has no parameter named key.
+type ParamReader interface {
+	Param(r *http.Request, key string) (value string, ok bool)
+}
+
+// ParamReaderFunc adapts a function to ParamReader.
+type ParamReaderFunc func(r *http.Request, key string) (string, bool)
+
+func (f ParamReaderFunc) Param(r *http.Request, key string) (string, bool) {
+	return f(r, key)
+}
+
+// Params is the ParamReader used by ReadParam and the readers built on it. It
+// defaults to ChiParams; set it once at start-up for other routers:
+//
+//	helpers.Params = helpers.VarsParams(mux.Vars)  // gorilla/mux
+//	helpers.Params = helpers.PathValueParams        // net/http on Go 1.22+
+var Params ParamReader = ChiParams
+
+// ChiParams reads parameters from the chi route context.
+var ChiParams ParamReader = ParamReaderFunc(func(r *http.Request, key string) (string, bool) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "", false
+	}
+
+	// Search from the end like chi.URLParam, so the innermost route wins.
+	for i := len(rctx.URLParams.Keys) - 1; i >= 0; i-- {
+		if rctx.URLParams.Keys[i] == key {
+			return rctx.URLParams.Values[i], true
+		}
+	}
+
+	return "", false
+})
+
+// VarsParams reads parameters from a map-returning function such as gorilla/mux's
+// mux.Vars, without this package depending on the router.
+func VarsParams(vars func(*http.Request) map[string]string) ParamReader {
+	return ParamReaderFunc(func(r *http.Request, key string) (string, bool) {
+		v, ok := vars(r)[key]
+		return v, ok
+	})
+}
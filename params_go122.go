@@ -0,0 +1,14 @@
+//go:build go1.22
+
+package helpers
+
+import "net/http"
+
+// PathValueParams reads parameters set by the Go 1.22 http.ServeMux patterns. PathValue
+// does not tell a missing wildcard from an empty one, so an empty value reports ok
+// as false. ServeMux only sets path values when the main module declares go 1.22 or
+// later (or GODEBUG=httpmuxgo121=0).
+var PathValueParams ParamReader = ParamReaderFunc(func(r *http.Request, key string) (string, bool) {
+	v := r.PathValue(key)
+	return v, v != ""
+})
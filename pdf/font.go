@@ -0,0 +1,101 @@
+package pdf
+
+// Font is one of the two standard fonts every PDF reader has, so nothing is embedded.
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+func (f Font) resource() string {
+	if f == Bold {
+		return "/F2"
+	}
+	return "/F1"
+}
+
+// Glyph widths of the printable ASCII range in 1/1000 em, from the Adobe metrics.
+var widths = [2][95]uint16{
+	{ // Helvetica
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{ // Helvetica-Bold
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// winAnsi maps the non-Latin-1 characters of WinAnsiEncoding to their codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// latinBase gives the unaccented letter for 0xC0-0xFF, whose width it shares.
+const latinBase = "AAAAAA\x00CEEEEIIIIDNOOOOO\x00OUUUUY\x00\x00aaaaaa\x00ceeeeiiiidnooooo\x00ouuuuy\x00y"
+
+// encode converts s to WinAnsiEncoding, replacing characters outside it with '?'.
+func encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b = append(b, ' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		default:
+			if c, ok := winAnsi[r]; ok {
+				b = append(b, c)
+			} else {
+				b = append(b, '?')
+			}
+		}
+	}
+
+	return b
+}
+
+// charWidth returns the width of a WinAnsi code in 1/1000 em. Outside ASCII and the
+// accented letters it is approximate.
+func charWidth(f Font, c byte) int {
+	if c >= 0x20 && c < 0x7f {
+		return int(widths[f][c-0x20])
+	}
+	if c >= 0xc0 {
+		if base := latinBase[c-0xc0]; base != 0 {
+			return int(widths[f][base-0x20])
+		}
+	}
+	switch c {
+	case 0xa0:
+		return 278
+	case 0x85, 0x97, 0x99, 0xc6:
+		return 1000
+	case 0x91, 0x92:
+		return 222
+	}
+
+	return 556
+}
+
+// TextWidth returns the width of s in points when set in f at size.
+func TextWidth(f Font, size float64, s string) float64 {
+	w := 0
+	for _, c := range encode(s) {
+		w += charWidth(f, c)
+	}
+
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,407 @@
+// Package pdf lays out simple flowing documents such as invoices, receipts and
+// reports - headings, paragraphs, label/value lists and tables that break across
+// pages - and writes them as PDF without a browser or external tools. Text is set in
+// Helvetica using WinAnsiEncoding, so characters outside Western European scripts
+// are replaced with '?'.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageSize is a page's width and height in points (1/72 inch).
+type PageSize struct {
+	Width, Height float64
+}
+
+var (
+	A4     = PageSize{595.28, 841.89}
+	Letter = PageSize{612, 792}
+)
+
+type Align int
+
+const (
+	Left Align = iota
+	Center
+	Right
+)
+
+type Options struct {
+	// Size defaults to A4.
+	Size PageSize
+	// Margin on every side in points. Defaults to 48.
+	Margin float64
+	// FontSize of body text. Defaults to 10.
+	FontSize float64
+	Title    string
+	Author   string
+	// CreatedAt is recorded in the document information when set.
+	CreatedAt time.Time
+	// PageNumbers adds "Page N of M" to the bottom of every page.
+	PageNumbers bool
+}
+
+// Document collects content into pages. Its methods add blocks in reading order,
+// starting a new page whenever the next line would not fit.
+type Document struct {
+	opts  Options
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func New(opts Options) *Document {
+	if opts.Size == (PageSize{}) {
+		opts.Size = A4
+	}
+	if opts.Margin <= 0 {
+		opts.Margin = 48
+	}
+	if opts.FontSize <= 0 {
+		opts.FontSize = 10
+	}
+
+	d := &Document{opts: opts}
+	d.AddPage()
+
+	return d
+}
+
+// AddPage starts a new page.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = d.opts.Size.Height - d.opts.Margin
+}
+
+// PageCount returns the number of pages so far.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) contentWidth() float64 {
+	return d.opts.Size.Width - 2*d.opts.Margin
+}
+
+func (d *Document) leading(size float64) float64 {
+	return size * 1.4
+}
+
+// ensure starts a new page unless h points fit above the bottom margin. A block taller
+// than a whole page is still placed, and overflows.
+func (d *Document) ensure(h float64) {
+	top := d.opts.Size.Height - d.opts.Margin
+	if d.y-h < d.opts.Margin && d.y < top {
+		d.AddPage()
+	}
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// text draws s with its baseline at y, aligned within the box starting at x.
+func (d *Document) text(f Font, size, x, y, width float64, align Align, s string) {
+	switch align {
+	case Center:
+		x += (width - TextWidth(f, size, s)) / 2
+	case Right:
+		x += width - TextWidth(f, size, s)
+	}
+
+	fmt.Fprintf(d.page(), "BT %s %s Tf %s %s Td %s Tj ET\n", f.resource(), num(size), num(x), num(y), literal(encode(s)))
+}
+
+func (d *Document) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Heading adds s in bold at 1.6 times the body size.
+func (d *Document) Heading(s string) {
+	d.paragraph(Bold, d.opts.FontSize*1.6, Left, s)
+	d.y -= d.opts.FontSize * 0.4
+}
+
+// Text adds a paragraph wrapped to the page width. Newlines start new lines.
+func (d *Document) Text(s string) {
+	d.paragraph(Regular, d.opts.FontSize, Left, s)
+}
+
+// TextStyle adds a paragraph in the given font, size and alignment. A size of 0 is the
+// body size.
+func (d *Document) TextStyle(f Font, size float64, align Align, s string) {
+	if size <= 0 {
+		size = d.opts.FontSize
+	}
+	d.paragraph(f, size, align, s)
+}
+
+func (d *Document) paragraph(f Font, size float64, align Align, s string) {
+	lead := d.leading(size)
+	for _, l := range wrap(f, size, d.contentWidth(), s) {
+		d.ensure(lead)
+		d.y -= lead
+		d.text(f, size, d.opts.Margin, d.y+(lead-size)/2, d.contentWidth(), align, l)
+	}
+}
+
+// Space adds h points of vertical space.
+func (d *Document) Space(h float64) {
+	d.y -= h
+}
+
+// Rule adds a thin horizontal line across the page.
+func (d *Document) Rule() {
+	d.ensure(d.opts.FontSize)
+	d.y -= d.opts.FontSize / 2
+	d.line(d.opts.Margin, d.y, d.opts.Size.Width-d.opts.Margin, d.y, 0.5)
+	d.y -= d.opts.FontSize / 2
+}
+
+// Field is a label/value pair for Fields.
+type Field struct {
+	Label string
+	Value string
+	// Bold sets the whole row in bold, e.g. for a total.
+	Bold bool
+}
+
+// Fields adds label/value rows, with the labels in bold in a column sized to the
+// widest. With Right alignment the rows sit against the right margin, as totals under
+// a table do.
+func (d *Document) Fields(align Align, fields []Field) {
+	size := d.opts.FontSize
+	labelW, valueW := 0.0, 0.0
+	for _, f := range fields {
+		if w := TextWidth(Bold, size, f.Label); w > labelW {
+			labelW = w
+		}
+		if w := TextWidth(Bold, size, f.Value); w > valueW {
+			valueW = w
+		}
+	}
+	gap := size
+	if labelW+gap > d.contentWidth()/2 {
+		labelW = d.contentWidth()/2 - gap
+	}
+
+	x := d.opts.Margin
+	valueAlign := Left
+	if align == Right {
+		valueAlign = Right
+		if w := labelW + gap + valueW; w < d.contentWidth() {
+			x += d.contentWidth() - w
+		}
+	}
+	valueX := x + labelW + gap
+	valueWidth := d.opts.Size.Width - d.opts.Margin - valueX
+
+	lead := d.leading(size)
+	for _, f := range fields {
+		valueFont := Regular
+		if f.Bold {
+			valueFont = Bold
+		}
+		labels := wrap(Bold, size, labelW, f.Label)
+		values := wrap(valueFont, size, valueWidth, f.Value)
+		n := len(labels)
+		if len(values) > n {
+			n = len(values)
+		}
+
+		d.ensure(lead * float64(n))
+		top := d.y
+		for i := 0; i < n; i++ {
+			y := top - lead*float64(i+1) + (lead-size)/2
+			if i < len(labels) {
+				d.text(Bold, size, x, y, labelW, Left, labels[i])
+			}
+			if i < len(values) {
+				d.text(valueFont, size, valueX, y, valueWidth, valueAlign, values[i])
+			}
+		}
+		d.y = top - lead*float64(n)
+	}
+}
+
+// Column describes one column of a Table.
+type Column struct {
+	Header string
+	// Width is a fraction of the page width. Columns without one share what is left.
+	Width float64
+	Align Align
+}
+
+// Table adds rows under a bold header, wrapping cells to their column and repeating
+// the header on every page the table continues onto. Footer rows follow the body in
+// bold, after a rule.
+func (d *Document) Table(columns []Column, rows [][]string, footer [][]string) {
+	if len(columns) == 0 {
+		return
+	}
+
+	size := d.opts.FontSize
+	lead := d.leading(size)
+	pad := size / 2
+
+	widths := make([]float64, len(columns))
+	fixed, free := 0.0, 0
+	for _, c := range columns {
+		if c.Width > 0 {
+			fixed += c.Width
+		} else {
+			free++
+		}
+	}
+	for i, c := range columns {
+		w := c.Width
+		if w <= 0 {
+			w = 0
+			if free > 0 && fixed < 1 {
+				w = (1 - fixed) / float64(free)
+			}
+		} else if fixed > 1 {
+			w /= fixed
+		}
+		widths[i] = w * d.contentWidth()
+	}
+
+	row := func(f Font, cells []string) {
+		lines := make([][]string, len(columns))
+		n := 1
+		for i := range columns {
+			if i < len(cells) {
+				lines[i] = wrap(f, size, widths[i]-2*pad, cells[i])
+			}
+			if len(lines[i]) > n {
+				n = len(lines[i])
+			}
+		}
+
+		top := d.y
+		x := d.opts.Margin
+		for i, c := range columns {
+			for j, l := range lines[i] {
+				y := top - pad/2 - lead*float64(j+1) + (lead-size)/2
+				d.text(f, size, x+pad, y, widths[i]-2*pad, c.Align, l)
+			}
+			x += widths[i]
+		}
+		d.y = top - lead*float64(n) - pad
+	}
+	rowHeight := func(f Font, cells []string) float64 {
+		n := 1
+		for i := range columns {
+			if i < len(cells) {
+				if l := len(wrap(f, size, widths[i]-2*pad, cells[i])); l > n {
+					n = l
+				}
+			}
+		}
+		return lead*float64(n) + pad
+	}
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	header := func() {
+		row(Bold, headers)
+		d.line(d.opts.Margin, d.y, d.opts.Margin+d.contentWidth(), d.y, 0.75)
+	}
+
+	headerHeight := rowHeight(Bold, headers)
+	first := 0.0
+	if len(rows) > 0 {
+		first = rowHeight(Regular, rows[0])
+	}
+	d.ensure(headerHeight + first)
+	header()
+
+	for _, cells := range rows {
+		h := rowHeight(Regular, cells)
+		if d.y-h < d.opts.Margin {
+			d.AddPage()
+			header()
+		}
+		row(Regular, cells)
+	}
+
+	if len(footer) > 0 {
+		d.ensure(rowHeight(Bold, footer[0]))
+		d.line(d.opts.Margin, d.y, d.opts.Margin+d.contentWidth(), d.y, 0.5)
+		for _, cells := range footer {
+			d.ensure(rowHeight(Bold, cells))
+			row(Bold, cells)
+		}
+	}
+	d.y -= pad
+}
+
+// wrap breaks s into lines no wider than width, at spaces where possible.
+func wrap(f Font, size, width float64, s string) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		cur := ""
+		for _, w := range words {
+			next := w
+			if cur != "" {
+				next = cur + " " + w
+			}
+			if TextWidth(f, size, next) <= width {
+				cur = next
+				continue
+			}
+			if cur != "" {
+				lines = append(lines, cur)
+			}
+			// Break a word longer than the line.
+			for TextWidth(f, size, w) > width && len([]rune(w)) > 1 {
+				runes := []rune(w)
+				n := len(runes) - 1
+				for n > 1 && TextWidth(f, size, string(runes[:n])) > width {
+					n--
+				}
+				lines = append(lines, string(runes[:n]))
+				w = string(runes[n:])
+			}
+			cur = w
+		}
+		lines = append(lines, cur)
+	}
+
+	return lines
+}
+
+// num formats a coordinate with at most two decimals, as PDF numbers allow no exponent.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// literal returns b as a PDF literal string.
+func literal(b []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte(')')
+
+	return sb.String()
+}
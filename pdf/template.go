@@ -0,0 +1,226 @@
+package pdf
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+type BlockKind int
+
+const (
+	HeadingBlock BlockKind = iota
+	TextBlock
+	FieldsBlock
+	TableBlock
+	RuleBlock
+	SpaceBlock
+	PageBreakBlock
+)
+
+// FieldTemplate is a Field whose Label and Value are templates.
+type FieldTemplate struct {
+	Label string
+	Value string
+	Bold  bool
+}
+
+// ColumnTemplate is a table column whose Value template is run against each row.
+type ColumnTemplate struct {
+	Column
+	Value string
+}
+
+// Block is one part of a Template. Which fields apply depends on Kind. Every string,
+// except Rows, is a text/template run against the data map, or the row for column
+// values.
+type Block struct {
+	Kind BlockKind
+
+	// Text of a heading or text block.
+	Text string
+	// Font, Size and Align style a text block.
+	Font  Font
+	Size  float64
+	Align Align
+
+	// Fields of a fields block, laid out with Align.
+	Fields []FieldTemplate
+
+	// Columns and Rows, the data key of a slice with one element per row, make up a
+	// table block. Footer rows are run against the data map.
+	Columns []ColumnTemplate
+	Rows    string
+	Footer  [][]string
+
+	// Height of a space block in points.
+	Height float64
+
+	// When, if set, is a data key; the block is skipped unless its value is set and
+	// not a zero value or empty slice.
+	When string
+}
+
+// Template describes a document as blocks filled in from a data map, so the layout of
+// an invoice or receipt is declared once and rendered per request.
+type Template struct {
+	Options Options
+	Blocks  []Block
+	// Funcs are added to the template functions, e.g. for money formatting.
+	Funcs template.FuncMap
+}
+
+// Render builds the document for data. A template that fails to parse, or refers to a
+// key missing from data, is an error.
+func (t *Template) Render(data map[string]interface{}) (*Document, error) {
+	r := &renderer{funcs: t.Funcs}
+	d := New(t.Options)
+
+	for i, b := range t.Blocks {
+		if b.When != "" && !truthy(data[b.When]) {
+			continue
+		}
+
+		if err := r.block(d, b, data); err != nil {
+			return nil, fmt.Errorf("pdf: block %d: %w", i, err)
+		}
+	}
+
+	return d, nil
+}
+
+// WriteTemplate renders t for data and sends it as Write does.
+func WriteTemplate(w http.ResponseWriter, t *Template, data map[string]interface{}, filename string, download bool) error {
+	d, err := t.Render(data)
+	if err != nil {
+		return err
+	}
+
+	return Write(w, d, filename, download)
+}
+
+type renderer struct {
+	funcs template.FuncMap
+	cache map[string]*template.Template
+}
+
+func (r *renderer) exec(text string, dot interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, ok := r.cache[text]
+	if !ok {
+		var err error
+		tmpl, err = template.New("").Funcs(r.funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		if r.cache == nil {
+			r.cache = make(map[string]*template.Template)
+		}
+		r.cache[text] = tmpl
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, dot); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+func (r *renderer) block(d *Document, b Block, data map[string]interface{}) error {
+	switch b.Kind {
+	case HeadingBlock, TextBlock:
+		s, err := r.exec(b.Text, data)
+		if err != nil {
+			return err
+		}
+		if b.Kind == HeadingBlock {
+			d.Heading(s)
+		} else {
+			d.TextStyle(b.Font, b.Size, b.Align, s)
+		}
+
+	case FieldsBlock:
+		fields := make([]Field, 0, len(b.Fields))
+		for _, f := range b.Fields {
+			label, err := r.exec(f.Label, data)
+			if err != nil {
+				return err
+			}
+			value, err := r.exec(f.Value, data)
+			if err != nil {
+				return err
+			}
+			fields = append(fields, Field{Label: label, Value: value, Bold: f.Bold})
+		}
+		d.Fields(b.Align, fields)
+
+	case TableBlock:
+		columns := make([]Column, len(b.Columns))
+		for i, c := range b.Columns {
+			columns[i] = c.Column
+		}
+
+		var rows [][]string
+		if b.Rows != "" {
+			v := reflect.ValueOf(data[b.Rows])
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return fmt.Errorf("rows %q is %T, not a slice", b.Rows, data[b.Rows])
+			}
+			for i := 0; i < v.Len(); i++ {
+				row := make([]string, len(b.Columns))
+				for j, c := range b.Columns {
+					s, err := r.exec(c.Value, v.Index(i).Interface())
+					if err != nil {
+						return err
+					}
+					row[j] = s
+				}
+				rows = append(rows, row)
+			}
+		}
+
+		footer := make([][]string, len(b.Footer))
+		for i, cells := range b.Footer {
+			footer[i] = make([]string, len(cells))
+			for j, cell := range cells {
+				s, err := r.exec(cell, data)
+				if err != nil {
+					return err
+				}
+				footer[i][j] = s
+			}
+		}
+		d.Table(columns, rows, footer)
+
+	case RuleBlock:
+		d.Rule()
+
+	case SpaceBlock:
+		d.Space(b.Height)
+
+	case PageBreakBlock:
+		d.AddPage()
+	}
+
+	return nil
+}
+
+func truthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() > 0
+	}
+
+	return !rv.IsZero()
+}
@@ -0,0 +1,163 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteTo renders the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pw := &objWriter{w: w}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed; page i uses 6+2i and its content stream 7+2i.
+	n := len(d.pages)
+	kids := make([]byte, 0, n*8)
+	for i := 0; i < n; i++ {
+		kids = strconv.AppendInt(kids, int64(6+2*i), 10)
+		kids = append(kids, " 0 R "...)
+	}
+
+	pw.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(2, fmt.Sprintf("<< /Type /Pages /Count %d /Kids [ %s] >>", n, kids))
+	pw.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	pw.object(4, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	pw.object(5, d.info())
+
+	for i, page := range d.pages {
+		content := page.Bytes()
+		if d.opts.PageNumbers {
+			var footer bytes.Buffer
+			label := fmt.Sprintf("Page %d of %d", i+1, n)
+			size := d.opts.FontSize * 0.8
+			x := d.opts.Size.Width - d.opts.Margin - TextWidth(Regular, size, label)
+			fmt.Fprintf(&footer, "BT /F1 %s Tf %s %s Td %s Tj ET\n", num(size), num(x), num(d.opts.Margin/2), literal(encode(label)))
+			content = append(append([]byte{}, content...), footer.Bytes()...)
+		}
+
+		pw.object(6+2*i, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(d.opts.Size.Width), num(d.opts.Size.Height), 7+2*i))
+		pw.stream(7+2*i, content)
+	}
+
+	xref := pw.n
+	size := 6 + 2*n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", size)
+	for i := 1; i < size; i++ {
+		pw.printf("%010d 00000 n \n", pw.offsets[i])
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, xref)
+
+	return pw.n, pw.err
+}
+
+func (d *Document) info() string {
+	s := "<< /Producer (go-helpers/pdf)"
+	if d.opts.Title != "" {
+		s += " /Title " + textString(d.opts.Title)
+	}
+	if d.opts.Author != "" {
+		s += " /Author " + textString(d.opts.Author)
+	}
+	if !d.opts.CreatedAt.IsZero() {
+		s += " /CreationDate (D:" + d.opts.CreatedAt.UTC().Format("20060102150405") + "Z)"
+	}
+
+	return s + " >>"
+}
+
+// textString encodes s as UTF-16BE, which document information strings allow.
+func textString(s string) string {
+	buf := []byte("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		buf = append(buf, fmt.Sprintf("%04X", u)...)
+	}
+
+	return string(append(buf, '>'))
+}
+
+// objWriter tracks the byte offset of each object for the cross-reference table.
+type objWriter struct {
+	w       io.Writer
+	n       int64
+	err     error
+	offsets map[int]int64
+}
+
+func (pw *objWriter) printf(format string, args ...interface{}) {
+	pw.write([]byte(fmt.Sprintf(format, args...)))
+}
+
+func (pw *objWriter) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *objWriter) object(id int, body string) {
+	pw.begin(id)
+	pw.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (pw *objWriter) stream(id int, content []byte) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(content)
+	zw.Close()
+
+	pw.begin(id)
+	pw.printf("%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", id, z.Len())
+	pw.write(z.Bytes())
+	pw.printf("\nendstream\nendobj\n")
+}
+
+func (pw *objWriter) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = make(map[int]int64)
+	}
+	pw.offsets[id] = pw.n
+}
+
+// Write sends the document as application/pdf with its Content-Length. A filename
+// is offered in Content-Disposition, as an attachment when download is set and for
+// display in the browser otherwise.
+func Write(w http.ResponseWriter, d *Document, filename string, download bool) error {
+	body, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if filename != "" {
+		disposition := "inline"
+		if download {
+			disposition = "attachment"
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+
+	return err
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimeParseError is returned by ReadTime and ReadDate when the value matches none of
+// the accepted layouts.
+type TimeParseError struct {
+	Key     string
+	Value   string
+	Layouts []string
+}
+
+func (e *TimeParseError) Error() string {
+	return fmt.Sprintf("%s must be a valid time such as %s", e.Key, strings.Join(e.Layouts, " or "))
+}
+
+// ReadTime reads a time from the query string, trying RFC 3339 and then layouts in
+// order. Times parsed without a zone are in UTC. It returns defaultValue when key is
+// missing, and defaultValue with a *TimeParseError when the value cannot be parsed.
+func ReadTime(qs url.Values, key string, layouts []string, defaultValue time.Time) (time.Time, bool, error) {
+	return readTime(qs, key, append([]string{time.RFC3339}, layouts...), defaultValue)
+}
+
+// ReadDate reads a calendar date such as 2024-03-31 from the query string, trying
+// 2006-01-02 and then layouts in order. The result is midnight UTC of that date.
+func ReadDate(qs url.Values, key string, layouts []string, defaultValue time.Time) (time.Time, bool, error) {
+	t, exists, err := readTime(qs, key, append([]string{"2006-01-02"}, layouts...), defaultValue)
+	if err != nil || !exists {
+		return t, exists, err
+	}
+
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), true, nil
+}
+
+func readTime(qs url.Values, key string, layouts []string, defaultValue time.Time) (time.Time, bool, error) {
+	s := strings.TrimSpace(qs.Get(key))
+	if s == "" {
+		return defaultValue, false, nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, true, nil
+		}
+	}
+
+	return defaultValue, false, &TimeParseError{Key: key, Value: s, Layouts: layouts}
+}
+
+// ListParseError is returned by the CSV list readers for the first element that
+// cannot be parsed. Index is zero-based, in the message too, as in ReadJSONArray.
+type ListParseError struct {
+	Key   string
+	Index int
+	Value string
+	// Want describes the expected element, e.g. "an integer".
+	Want string
+}
+
+func (e *ListParseError) Error() string {
+	return fmt.Sprintf("%s: item %d (%q) must be %s", e.Key, e.Index, e.Value, e.Want)
+}
+
+// ReadInts reads a comma-separated list of integers such as ?ids=1,2,3. It returns
+// defaultValue when key is missing, and defaultValue with a *ListParseError when an
+// element is not an integer.
+func ReadInts(qs url.Values, key string, defaultValue []int) ([]int, bool, error) {
+	return readList(qs, key, defaultValue, "an integer", strconv.Atoi)
+}
+
+// ReadUUIDs reads a comma-separated list of UUIDs, reporting the first invalid one
+// as a *ListParseError. It returns nil when key is missing.
+func ReadUUIDs(qs url.Values, key string) ([]uuid.UUID, bool, error) {
+	return readList(qs, key, nil, "a valid UUID", uuid.Parse)
+}
+
+func readList[T any](qs url.Values, key string, defaultValue []T, want string, parse func(string) (T, error)) ([]T, bool, error) {
+	s := qs.Get(key)
+	if strings.TrimSpace(s) == "" {
+		return defaultValue, false, nil
+	}
+
+	parts := strings.Split(s, ",")
+	out := make([]T, len(parts))
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		v, err := parse(p)
+		if err != nil {
+			return defaultValue, false, &ListParseError{Key: key, Index: i, Value: p, Want: want}
+		}
+		out[i] = v
+	}
+
+	return out, true, nil
+}
+
+// EnumError is returned by ReadEnum for a value outside the safelist.
+type EnumError struct {
+	Key     string
+	Value   string
+	Allowed []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("%s must be one of %s", e.Key, strings.Join(e.Allowed, ", "))
+}
+
+// ReadEnum reads a value that must be one of allowed, such as ?status=open. It
+// returns defaultValue when key is missing, and defaultValue with an *EnumError when
+// the value is not allowed.
+func ReadEnum(qs url.Values, key string, allowed []string, defaultValue string) (string, bool, error) {
+	return readEnum(qs, key, allowed, defaultValue, false)
+}
+
+// ReadEnumFold is ReadEnum with case-insensitive matching. It returns the allowed
+// spelling, so ?status=OPEN yields "open".
+func ReadEnumFold(qs url.Values, key string, allowed []string, defaultValue string) (string, bool, error) {
+	return readEnum(qs, key, allowed, defaultValue, true)
+}
+
+func readEnum(qs url.Values, key string, allowed []string, defaultValue string, fold bool) (string, bool, error) {
+	s := strings.TrimSpace(qs.Get(key))
+	if s == "" {
+		return defaultValue, false, nil
+	}
+
+	for _, a := range allowed {
+		if s == a || (fold && strings.EqualFold(s, a)) {
+			return a, true, nil
+		}
+	}
+
+	return defaultValue, false, &EnumError{Key: key, Value: s, Allowed: allowed}
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	helpers "github.com/hasahmad/go-helpers"
+	"github.com/hasahmad/go-helpers/clock"
+	"github.com/hasahmad/go-helpers/httpmw"
+)
+
+// Option configures RateLimit.
+type Option func(*rateLimitConfig)
+
+type rateLimitConfig struct {
+	key        func(r *http.Request) string
+	ttl        time.Duration
+	interval   time.Duration
+	ctx        context.Context
+	onLimited  func(r *http.Request, key string)
+	onRejected func(w http.ResponseWriter, r *http.Request)
+	clock      clock.Clock
+}
+
+// WithKey identifies clients by the returned key instead of their IP, e.g. an API key
+// or user ID. Requests with an empty key are not limited.
+func WithKey(fn func(r *http.Request) string) Option {
+	return func(c *rateLimitConfig) { c.key = fn }
+}
+
+// WithCleanup drops clients not seen for ttl, checking every interval. Defaults to
+// three minutes and one minute; ttl is raised to the time a bucket takes to refill, as
+// dropping a client sooner would hand it a fresh burst.
+func WithCleanup(ttl, interval time.Duration) Option {
+	return func(c *rateLimitConfig) { c.ttl, c.interval = ttl, interval }
+}
+
+// WithContext stops the cleanup goroutine when ctx is done. Without it the goroutine
+// lives as long as the process.
+func WithContext(ctx context.Context) Option {
+	return func(c *rateLimitConfig) { c.ctx = ctx }
+}
+
+// WithOnLimited is called for every rejected request, e.g. for metrics.
+func WithOnLimited(fn func(r *http.Request, key string)) Option {
+	return func(c *rateLimitConfig) { c.onLimited = fn }
+}
+
+// WithResponse replaces the 429 written for rejected requests. Retry-After is already
+// set when it is called.
+func WithResponse(fn func(w http.ResponseWriter, r *http.Request)) Option {
+	return func(c *rateLimitConfig) { c.onRejected = fn }
+}
+
+// WithClock sets the time source, for tests.
+func WithClock(c clock.Clock) Option {
+	return func(cfg *rateLimitConfig) { cfg.clock = c }
+}
+
+type client struct {
+	limiter  *Limiter
+	lastSeen time.Time
+}
+
+// RateLimit gives every client its own token bucket of rps tokens per second and
+// burst size, keyed by httpmw.ClientIP unless WithKey is used; put it after
+// httpmw.RealIP behind a proxy. Requests over the limit get the 429 JSON envelope of
+// helpers.RateLimitExceededResponse with Retry-After set to when a token is next
+// available. Idle clients are dropped in the background.
+func RateLimit(rps float64, burst int, opts ...Option) func(http.Handler) http.Handler {
+	cfg := rateLimitConfig{
+		key:        httpmw.ClientIP,
+		ttl:        3 * time.Minute,
+		interval:   time.Minute,
+		ctx:        context.Background(),
+		onRejected: helpers.RateLimitExceededResponse,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.clock = clock.Or(cfg.clock)
+	if burst < 1 {
+		burst = 1
+	}
+	if rps > 0 {
+		if refill := time.Duration(float64(burst) / rps * float64(time.Second)); refill > cfg.ttl {
+			cfg.ttl = refill
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		clients = map[string]*client{}
+	)
+
+	if cfg.interval > 0 {
+		go func() {
+			t := cfg.clock.NewTicker(cfg.interval)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-cfg.ctx.Done():
+					return
+				case now := <-t.C():
+					mu.Lock()
+					for k, c := range clients {
+						if now.Sub(c.lastSeen) > cfg.ttl {
+							delete(clients, k)
+						}
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := cfg.key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := cfg.clock.Now()
+			mu.Lock()
+			c, ok := clients[key]
+			if !ok {
+				c = &client{limiter: NewWithClock(rps, burst, cfg.clock)}
+				clients[key] = c
+			}
+			c.lastSeen = now
+			mu.Unlock()
+
+			allowed, remaining, wait := c.limiter.take()
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(burst))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+			if !allowed {
+				if cfg.onLimited != nil {
+					cfg.onLimited(r, key)
+				}
+				h.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+				cfg.onRejected(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// take is Allow that also returns the whole tokens left and, when refused, how long
+// until the next token.
+func (l *Limiter) take() (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(l.clock.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, int(l.tokens), 0
+	}
+	if l.rate <= 0 {
+		return false, 0, time.Hour
+	}
+
+	return false, 0, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	helpers "github.com/hasahmad/go-helpers"
+	"github.com/hasahmad/go-helpers/clock"
+)
+
+// QuotaStore counts usage per key in fixed windows. Incr adds one to the count of
+// the window containing now and returns the new count and when the window ends.
+// Implementations backed by Redis can use INCR with PEXPIRE on first use.
+type QuotaStore interface {
+	Incr(ctx context.Context, key string, window time.Duration, now time.Time) (int64, time.Time, error)
+}
+
+// MemoryQuotaStore is an in-process QuotaStore. Expired windows are dropped as keys
+// are used again; call Sweep periodically when keys are unbounded.
+type MemoryQuotaStore struct {
+	mu      sync.Mutex
+	windows map[string]quotaWindow
+}
+
+type quotaWindow struct {
+	count int64
+	reset time.Time
+}
+
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{windows: map[string]quotaWindow{}}
+}
+
+func (s *MemoryQuotaStore) Incr(_ context.Context, key string, window time.Duration, now time.Time) (int64, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = quotaWindow{reset: now.Truncate(window).Add(window)}
+	}
+	w.count++
+	s.windows[key] = w
+
+	return w.count, w.reset, nil
+}
+
+// Sweep drops windows which ended before now.
+func (s *MemoryQuotaStore) Sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, w := range s.windows {
+		if !now.Before(w.reset) {
+			delete(s.windows, k)
+		}
+	}
+}
+
+type QuotaOptions struct {
+	// Limit is the number of requests allowed per key per Window.
+	Limit int64
+	// Window defaults to one hour.
+	Window time.Duration
+	// Store defaults to a MemoryQuotaStore.
+	Store QuotaStore
+	// Key identifies the caller. Defaults to the X-API-Key header; requests with an
+	// empty key are not counted.
+	Key func(r *http.Request) string
+	// Soft only reports requests over the limit through OnExceeded instead of
+	// rejecting them, for rolling limits out to existing customers.
+	Soft bool
+	// OnExceeded is called for every request over the limit, in both modes.
+	OnExceeded func(r *http.Request, key string, u Usage)
+	// OnError is called when the store fails. The request is let through.
+	OnError func(r *http.Request, err error)
+	// Response writes the 429 for rejected requests, with Retry-After already set.
+	// Defaults to helpers.RateLimitExceededResponse, as RateLimit uses.
+	Response func(w http.ResponseWriter, r *http.Request)
+	// Clock defaults to the real clock.
+	Clock clock.Clock
+}
+
+// Usage is the state of a key's quota after a request.
+type Usage struct {
+	Limit     int64
+	Remaining int64
+	Reset     time.Time
+	Exceeded  bool
+}
+
+// Quota enforces, or in soft mode only reports, per-key request quotas over fixed
+// windows.
+type Quota struct {
+	opts QuotaOptions
+}
+
+func NewQuota(opts QuotaOptions) *Quota {
+	if opts.Window <= 0 {
+		opts.Window = time.Hour
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryQuotaStore()
+	}
+	if opts.Key == nil {
+		opts.Key = func(r *http.Request) string { return r.Header.Get("X-API-Key") }
+	}
+	if opts.Response == nil {
+		opts.Response = helpers.RateLimitExceededResponse
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	return &Quota{opts: opts}
+}
+
+// Use counts one request for key.
+func (q *Quota) Use(ctx context.Context, key string) (Usage, error) {
+	count, reset, err := q.opts.Store.Incr(ctx, "quota:"+key, q.opts.Window, q.opts.Clock.Now())
+	if err != nil {
+		return Usage{}, err
+	}
+
+	u := Usage{Limit: q.opts.Limit, Remaining: q.opts.Limit - count, Reset: reset}
+	if u.Remaining < 0 {
+		u.Remaining = 0
+		u.Exceeded = true
+	}
+
+	return u, nil
+}
+
+// Middleware counts each request against its key's quota and always sets the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (Unix seconds)
+// headers. Requests over the limit get a 429 with Retry-After unless Soft is set.
+func (q *Quota) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := q.opts.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u, err := q.Use(r.Context(), key)
+		if err != nil {
+			if q.opts.OnError != nil {
+				q.opts.OnError(r, err)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.FormatInt(u.Limit, 10))
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(u.Remaining, 10))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(u.Reset.Unix(), 10))
+
+		if u.Exceeded {
+			if q.opts.OnExceeded != nil {
+				q.opts.OnExceeded(r, key, u)
+			}
+			if !q.opts.Soft {
+				retry := u.Reset.Sub(q.opts.Clock.Now())
+				h.Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+				q.opts.Response(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
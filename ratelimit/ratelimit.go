@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/clock"
+)
+
+var (
+	ErrLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// Limiter is a token bucket which refills at Rate tokens per second up to Burst tokens.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+// New creates a Limiter allowing rate events per second with the given burst size. The
+// bucket starts full. A burst smaller than 1 is treated as 1.
+func New(rate float64, burst int) *Limiter {
+	return NewWithClock(rate, burst, clock.Real)
+}
+
+// NewWithClock is New with an explicit time source, for tests.
+func NewWithClock(rate float64, burst int, c clock.Clock) *Limiter {
+	c = clock.Or(c)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   c.Now(),
+		clock:  c,
+	}
+}
+
+// advance refills the bucket for the time elapsed since the last call. The caller must
+// hold the mutex.
+func (l *Limiter) advance(now time.Time) {
+	if now.Before(l.last) {
+		return
+	}
+	elapsed := now.Sub(l.last).Seconds()
+	l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	l.last = now
+}
+
+// Allow reports whether an event may happen now and consumes a token if so.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(l.clock.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+
+	return false
+}
+
+// reserve consumes a token, possibly going into debt, and returns how long the caller
+// must wait before acting on it.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(l.clock.Now())
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a token consumed by reserve when the caller gave up waiting.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
+
+// Wait blocks until a token is available or ctx is done. If ctx has a deadline which
+// would expire before a token becomes available, Wait returns ErrLimitExceeded straight
+// away instead of sleeping.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.clock.Now()) < delay {
+		l.cancel()
+		return ErrLimitExceeded
+	}
+
+	t := l.clock.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of tokens currently available.
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(l.clock.Now())
+	return l.tokens
+}
@@ -0,0 +1,320 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hasahmad/go-helpers/internal/msgpack"
+)
+
+// ResponseEncoder writes data in one media type.
+type ResponseEncoder func(w io.Writer, data interface{}) error
+
+var (
+	responseEncodersMu sync.RWMutex
+	// responseEncoders are kept in registration order, which breaks ties when the
+	// client accepts several types equally.
+	responseEncoders []registeredEncoder
+)
+
+type registeredEncoder struct {
+	mediaType string
+	encode    ResponseEncoder
+}
+
+func init() {
+	RegisterResponseEncoder("application/json", encodeJSON)
+	RegisterResponseEncoder("application/xml", encodeXML)
+	RegisterResponseEncoder("text/xml", encodeXML)
+	RegisterResponseEncoder("text/csv", encodeCSV)
+	RegisterResponseEncoder("application/msgpack", msgpack.Encode)
+	RegisterResponseEncoder("application/x-msgpack", msgpack.Encode)
+}
+
+// RegisterResponseEncoder makes Respond able to answer with mediaType, replacing any
+// encoder already registered for it.
+func RegisterResponseEncoder(mediaType string, enc ResponseEncoder) {
+	responseEncodersMu.Lock()
+	defer responseEncodersMu.Unlock()
+
+	for i := range responseEncoders {
+		if responseEncoders[i].mediaType == mediaType {
+			responseEncoders[i].encode = enc
+			return
+		}
+	}
+	responseEncoders = append(responseEncoders, registeredEncoder{mediaType: mediaType, encode: enc})
+}
+
+// Respond encodes data in the best media type the Accept header allows among the
+// registered encoders: JSON, XML, CSV and MessagePack out of the box. JSON is used
+// when the header is missing or nothing matches. XML, CSV and MessagePack encode the
+// JSON form of data, so json struct tags apply to every format. CSV needs a list of
+// objects, or an envelope holding one, such as Envelope{"movies": movies}.
+//
+// Nothing is written if encoding fails.
+func Respond(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	mediaType, enc := negotiateEncoder(r.Header.Get("Accept"))
+
+	var buf bytes.Buffer
+	if err := enc(&buf, data); err != nil {
+		return err
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if strings.HasPrefix(mediaType, "text/") {
+		mediaType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func negotiateEncoder(accept string) (string, ResponseEncoder) {
+	responseEncodersMu.RLock()
+	defer responseEncodersMu.RUnlock()
+
+	type accepted struct {
+		mediaType string
+		q         float64
+	}
+	var prefs []accepted
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, accepted{mediaType: mt, q: q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		for _, e := range responseEncoders {
+			if mediaTypeMatches(p.mediaType, e.mediaType) {
+				return e.mediaType, e.encode
+			}
+		}
+	}
+
+	for _, e := range responseEncoders {
+		if e.mediaType == "application/json" {
+			return e.mediaType, e.encode
+		}
+	}
+
+	return "application/json", encodeJSON
+}
+
+func mediaTypeMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+		return strings.HasPrefix(mediaType, prefix)
+	}
+
+	return false
+}
+
+func encodeJSON(w io.Writer, data interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(js, '\n'))
+
+	return err
+}
+
+// genericJSON returns data's JSON form as maps, slices and scalars, with numbers kept
+// as json.Number.
+func genericJSON(data interface{}) (interface{}, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// encodeXML writes data under a <response> root, object keys as elements and list
+// items as <item> elements.
+func encodeXML(w io.Writer, data interface{}) error {
+	v, err := genericJSON(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXMLValue(enc, "response", v); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+func writeXMLValue(enc *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(val) {
+			if err := writeXMLValue(enc, k, val[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if err := writeXMLValue(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(val))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName makes a JSON key usable as an element name.
+func xmlName(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case (r >= '0' && r <= '9' || r == '-' || r == '.') && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+
+	return b.String()
+}
+
+// encodeCSV writes a list of objects with a header row of their sorted keys. Nested
+// values are written as JSON.
+func encodeCSV(w io.Writer, data interface{}) error {
+	v, err := genericJSON(data)
+	if err != nil {
+		return err
+	}
+
+	// Unwrap an envelope holding a single list.
+	if m, ok := v.(map[string]interface{}); ok {
+		var lists []interface{}
+		for _, val := range m {
+			if l, ok := val.([]interface{}); ok {
+				lists = append(lists, l)
+			}
+		}
+		if len(lists) != 1 {
+			return fmt.Errorf("csv: response must contain exactly one list")
+		}
+		v = lists[0]
+	}
+	rows, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("csv: response must be a list of objects")
+	}
+
+	seen := map[string]bool{}
+	var columns []string
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("csv: response must be a list of objects")
+		}
+		for k := range obj {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		obj := row.(map[string]interface{})
+		for i, col := range columns {
+			record[i] = csvCell(obj[col])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func csvCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	}
+
+	js, _ := json.Marshal(v)
+	return string(js)
+}
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryAfter is the Retry-After hint used when a route declares no policy.
+var DefaultRetryAfter = 5 * time.Second
+
+// RetryPolicy tells clients whether, and when, a failed request may be repeated. The
+// error writers send it on 429, 502, 503 and 504 responses as Retry-After and
+// X-Retry-Safe headers.
+type RetryPolicy struct {
+	// Safe reports that repeating the request cannot apply its effects twice.
+	Safe bool
+	// After is the delay the client should wait. Defaults to DefaultRetryAfter.
+	After time.Duration
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy declares the retry policy of the routes it wraps:
+//
+//	r.With(helpers.WithRetryPolicy(helpers.RetryPolicy{Safe: true, After: time.Second})).
+//		Post("/v1/search", app.search)
+func WithRetryPolicy(p RetryPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, SetRetryPolicy(r, p))
+		})
+	}
+}
+
+// SetRetryPolicy returns r carrying p, for handlers that only know their policy once
+// running, such as when a dependency reports how long it will be down.
+func SetRetryPolicy(r *http.Request, p RetryPolicy) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), retryPolicyKey{}, p))
+}
+
+// RequestRetryPolicy returns the policy declared for r. Without one, GET, HEAD,
+// OPTIONS, PUT and DELETE requests, which HTTP defines as idempotent, and requests
+// sent with an Idempotency-Key header are safe to retry.
+func RequestRetryPolicy(r *http.Request) RetryPolicy {
+	p, ok := r.Context().Value(retryPolicyKey{}).(RetryPolicy)
+	if !ok {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+			p.Safe = true
+		default:
+			p.Safe = r.Header.Get("Idempotency-Key") != ""
+		}
+	}
+	if p.After <= 0 {
+		p.After = DefaultRetryAfter
+	}
+
+	return p
+}
+
+// setRetryHeaders adds the retry hints for statuses a client may retry. A
+// Retry-After already set, e.g. by a rate limiter, is kept.
+func setRetryHeaders(w http.ResponseWriter, r *http.Request, status int) {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return
+	}
+
+	p := RequestRetryPolicy(r)
+	if w.Header().Get("Retry-After") == "" {
+		secs := int64((p.After + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
+	w.Header().Set("X-Retry-Safe", strconv.FormatBool(p.Safe))
+}
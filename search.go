@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchTerm is one term of a search query. Field is empty for free text.
+type SearchTerm struct {
+	Field   string
+	Value   string
+	Negated bool
+}
+
+// SearchQuery is a parsed GitHub-style search such as
+// `status:open author:"jane doe" -archived term`.
+type SearchQuery struct {
+	Terms []SearchTerm
+}
+
+// ParseSearch parses a search box query. Terms are separated by spaces; field:value
+// filters a field, double quotes group words into one value and a leading - negates a
+// term. Field names are lowercased. Unbalanced quotes run to the end of the input.
+func ParseSearch(s string) SearchQuery {
+	var q SearchQuery
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		var tok string
+		tok, rest = nextSearchToken(rest)
+
+		term := SearchTerm{}
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			term.Negated = true
+			tok = tok[1:]
+		}
+		if !strings.HasPrefix(tok, `"`) {
+			if field, value, ok := strings.Cut(tok, ":"); ok && field != "" && value != "" {
+				term.Field = strings.ToLower(field)
+				tok = value
+			}
+		}
+		term.Value = unquoteSearch(tok)
+		if term.Value == "" {
+			continue
+		}
+		q.Terms = append(q.Terms, term)
+	}
+
+	return q
+}
+
+// nextSearchToken splits off the first token, keeping quoted spans together.
+func nextSearchToken(s string) (string, string) {
+	inQuote := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			return s[:i], strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+		}
+	}
+
+	return s, ""
+}
+
+func unquoteSearch(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, `"`, ""))
+}
+
+// Get returns the value of the first non-negated filter on field.
+func (q SearchQuery) Get(field string) (string, bool) {
+	for _, t := range q.Terms {
+		if t.Field == field && !t.Negated {
+			return t.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Text returns the non-negated free text terms joined by spaces.
+func (q SearchQuery) Text() string {
+	var words []string
+	for _, t := range q.Terms {
+		if t.Field == "" && !t.Negated {
+			words = append(words, t.Value)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// SearchSQLOptions maps a SearchQuery onto columns. Column names are written into SQL
+// as is and must not come from user input.
+type SearchSQLOptions struct {
+	// Fields maps search fields to columns, e.g. {"status": "status", "author":
+	// "author_name"}. Other fields are rejected.
+	Fields map[string]string
+	// TextColumns are matched against free text with a case-insensitive LIKE. Free
+	// text is rejected when empty.
+	TextColumns []string
+}
+
+// SQL renders the query as AND-ed conditions with ? placeholders, suitable for a
+// WHERE clause, such as
+//
+//	status = ? AND NOT (LOWER(title) LIKE ? ESCAPE '!' OR LOWER(body) LIKE ? ESCAPE '!')
+//
+// Field filters compare for equality and free text matches as a substring. It
+// returns "" for an empty query and an error naming any term that cannot be mapped.
+func (q SearchQuery) SQL(opts SearchSQLOptions) (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+	for _, t := range q.Terms {
+		var cond string
+		if t.Field != "" {
+			col, ok := opts.Fields[t.Field]
+			if !ok {
+				return "", nil, fmt.Errorf("unknown search field %q, expected one of %s", t.Field, strings.Join(searchFieldNames(opts.Fields), ", "))
+			}
+			cond = col + " = ?"
+			args = append(args, t.Value)
+		} else {
+			if len(opts.TextColumns) == 0 {
+				return "", nil, fmt.Errorf("free text search is not supported, use field:value")
+			}
+			pattern := "%" + EscapeLike(strings.ToLower(t.Value)) + "%"
+			likes := make([]string, len(opts.TextColumns))
+			for i, col := range opts.TextColumns {
+				likes[i] = "LOWER(" + col + `) LIKE ? ESCAPE '!'`
+				args = append(args, pattern)
+			}
+			cond = strings.Join(likes, " OR ")
+			if len(likes) > 1 && !t.Negated {
+				cond = "(" + cond + ")"
+			}
+		}
+
+		if t.Negated {
+			cond = "NOT (" + cond + ")"
+		}
+		conds = append(conds, cond)
+	}
+
+	return strings.Join(conds, " AND "), args, nil
+}
+
+// EscapeLike escapes the LIKE wildcards % and _ in s with !, for use with
+// ESCAPE '!'. A backslash is avoided as the escape character because MySQL treats it
+// specially inside string literals.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
+
+func searchFieldNames(fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
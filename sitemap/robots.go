@@ -0,0 +1,80 @@
+package sitemap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RobotsGroup is a set of rules for some user agents.
+type RobotsGroup struct {
+	// UserAgents defaults to "*".
+	UserAgents []string
+	Allow      []string
+	Disallow   []string
+	// CrawlDelay is left out when zero. Google ignores it; Bing and Yandex honour it.
+	CrawlDelay time.Duration
+}
+
+type RobotsOptions struct {
+	Groups []RobotsGroup
+	// Sitemaps are absolute URLs of sitemaps or sitemap indexes.
+	Sitemaps []string
+	// DisallowAll overrides Groups with a rule keeping every crawler out, e.g. for
+	// staging environments.
+	DisallowAll bool
+}
+
+// Robots renders the robots.txt for opts. With no groups every path is allowed.
+func Robots(opts RobotsOptions) string {
+	groups := opts.Groups
+	if opts.DisallowAll {
+		groups = []RobotsGroup{{Disallow: []string{"/"}}}
+	}
+	if len(groups) == 0 {
+		groups = []RobotsGroup{{Disallow: []string{""}}}
+	}
+
+	var b strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		agents := g.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, ua := range agents {
+			b.WriteString("User-agent: " + ua + "\n")
+		}
+		for _, p := range g.Allow {
+			b.WriteString("Allow: " + p + "\n")
+		}
+		for _, p := range g.Disallow {
+			b.WriteString("Disallow: " + p + "\n")
+		}
+		if g.CrawlDelay > 0 {
+			b.WriteString("Crawl-delay: " + strconv.FormatFloat(g.CrawlDelay.Seconds(), 'f', -1, 64) + "\n")
+		}
+	}
+	if len(opts.Sitemaps) > 0 {
+		b.WriteString("\n")
+		for _, s := range opts.Sitemaps {
+			b.WriteString("Sitemap: " + s + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// RobotsHandler serves Robots(opts), rendered once.
+func RobotsHandler(opts RobotsOptions) http.Handler {
+	body := []byte(Robots(opts))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write(body)
+	})
+}
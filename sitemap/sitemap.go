@@ -0,0 +1,332 @@
+// Package sitemap writes sitemap.xml files following the sitemaps.org protocol,
+// splitting large sites into several files under a sitemap index, and serves
+// robots.txt.
+package sitemap
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxURLs and MaxBytes are the protocol limits for one sitemap file.
+	MaxURLs  = 50_000
+	MaxBytes = 50 << 20
+
+	xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+var (
+	// ErrFull is returned by Encoder.Encode when the URL would take the file over
+	// MaxURLs or MaxBytes. The URL is not written.
+	ErrFull     = errors.New("sitemap: file is full")
+	ErrEmptyLoc = errors.New("sitemap: URL has no location")
+)
+
+type ChangeFreq string
+
+const (
+	Always  ChangeFreq = "always"
+	Hourly  ChangeFreq = "hourly"
+	Daily   ChangeFreq = "daily"
+	Weekly  ChangeFreq = "weekly"
+	Monthly ChangeFreq = "monthly"
+	Yearly  ChangeFreq = "yearly"
+	Never   ChangeFreq = "never"
+)
+
+// URL is one page of a sitemap. Loc must be absolute; the other fields are left out
+// when zero.
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq ChangeFreq
+	// Priority ranges from 0.1 to 1.0.
+	Priority float64
+}
+
+// Source yields the URLs of a sitemap one at a time, typically while iterating over
+// database rows, and returns yield's error if it fails.
+type Source func(ctx context.Context, yield func(URL) error) error
+
+// Encoder streams URLs into one sitemap file.
+type Encoder struct {
+	w      *bufio.Writer
+	n      int
+	bytes  int
+	opened bool
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+const (
+	urlsetOpen  = xml.Header + `<urlset xmlns="` + xmlns + `">` + "\n"
+	urlsetClose = "</urlset>\n"
+)
+
+// Encode writes u, returning ErrFull when the file has reached the protocol limits.
+func (e *Encoder) Encode(u URL) error {
+	if u.Loc == "" {
+		return ErrEmptyLoc
+	}
+
+	var b strings.Builder
+	b.WriteString("<url><loc>")
+	xml.EscapeText(&b, []byte(u.Loc))
+	b.WriteString("</loc>")
+	if !u.LastMod.IsZero() {
+		b.WriteString("<lastmod>" + u.LastMod.UTC().Format(time.RFC3339) + "</lastmod>")
+	}
+	if u.ChangeFreq != "" {
+		b.WriteString("<changefreq>" + string(u.ChangeFreq) + "</changefreq>")
+	}
+	if u.Priority > 0 {
+		b.WriteString("<priority>" + strconv.FormatFloat(u.Priority, 'f', 1, 64) + "</priority>")
+	}
+	b.WriteString("</url>\n")
+
+	return e.write(b.String())
+}
+
+func (e *Encoder) write(entry string) error {
+	if !e.opened {
+		if _, err := e.w.WriteString(urlsetOpen); err != nil {
+			return err
+		}
+		e.opened = true
+		e.bytes = len(urlsetOpen) + len(urlsetClose)
+	}
+	if e.n >= MaxURLs || e.bytes+len(entry) > MaxBytes {
+		return ErrFull
+	}
+
+	if _, err := e.w.WriteString(entry); err != nil {
+		return err
+	}
+	e.n++
+	e.bytes += len(entry)
+
+	return nil
+}
+
+// Len returns the number of URLs written.
+func (e *Encoder) Len() int {
+	return e.n
+}
+
+// Close ends the document and flushes it. It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if !e.opened {
+		if _, err := e.w.WriteString(urlsetOpen); err != nil {
+			return err
+		}
+		e.opened = true
+	}
+	if _, err := e.w.WriteString(urlsetClose); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+// Handler serves the URLs of src as a single sitemap, generated on each request. It
+// suits sites under MaxURLs; use Generate for larger ones. URLs past the limit are
+// dropped.
+func Handler(src Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+
+		enc := NewEncoder(w)
+		err := src(r.Context(), func(u URL) error {
+			return enc.Encode(u)
+		})
+		if err != nil && !errors.Is(err, ErrFull) {
+			// Nothing sensible can be sent once the body has started.
+			if enc.Len() == 0 {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+		enc.Close()
+	})
+}
+
+type GenerateOptions struct {
+	// BaseURL is where the generated files are served, e.g.
+	// "https://example.com/sitemaps/". It prefixes the file names in the index.
+	BaseURL string
+	// Create opens a file for writing. Defaults to DirCreator(".").
+	Create func(name string) (io.WriteCloser, error)
+	// Name is the stem of the file names. Defaults to "sitemap".
+	Name string
+}
+
+// Generate writes the URLs of src to files named sitemap-1.xml, sitemap-2.xml, ...,
+// starting a new one whenever a file is full, then writes the sitemap.xml index
+// listing them, which is the file to submit and reference from robots.txt. It returns
+// the names of every file written, the index last. Files from DirCreator replace the
+// old ones only if every file was written; on an error they are all discarded.
+func Generate(ctx context.Context, src Source, opts GenerateOptions) ([]string, error) {
+	if opts.Create == nil {
+		opts.Create = DirCreator(".")
+	}
+	if opts.Name == "" {
+		opts.Name = "sitemap"
+	}
+
+	var files []string
+	var pending []*renameOnClose
+	create := func(name string) (io.WriteCloser, error) {
+		w, err := opts.Create(name)
+		if rf, ok := w.(*renameOnClose); ok && err == nil {
+			rf.deferred = true
+			pending = append(pending, rf)
+		}
+		return w, err
+	}
+	// Files from DirCreator are only renamed into place once everything is written.
+	done := func(err error) error {
+		for _, rf := range pending {
+			if err != nil {
+				rf.abort()
+			} else if rerr := rf.commit(); rerr != nil {
+				err = rerr
+			}
+		}
+		return err
+	}
+
+	var f io.WriteCloser
+	var enc *Encoder
+	finish := func() error {
+		if enc == nil {
+			return nil
+		}
+		err := enc.Close()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		enc, f = nil, nil
+		return err
+	}
+	open := func() error {
+		name := fmt.Sprintf("%s-%d.xml", opts.Name, len(files)+1)
+		var err error
+		if f, err = create(name); err != nil {
+			return err
+		}
+		files = append(files, name)
+		enc = NewEncoder(f)
+		return nil
+	}
+
+	err := src(ctx, func(u URL) error {
+		if enc == nil {
+			if err := open(); err != nil {
+				return err
+			}
+		}
+		err := enc.Encode(u)
+		if !errors.Is(err, ErrFull) {
+			return err
+		}
+		if err := finish(); err != nil {
+			return err
+		}
+		if err := open(); err != nil {
+			return err
+		}
+		return enc.Encode(u)
+	})
+	if ferr := finish(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return files, done(err)
+	}
+
+	index := opts.Name + ".xml"
+	w, err := create(index)
+	if err != nil {
+		return files, done(err)
+	}
+	err = WriteIndex(w, opts.BaseURL, files, time.Now())
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+
+	return append(files, index), done(err)
+}
+
+// WriteIndex writes a sitemap index referencing files under baseURL, all modified at
+// lastMod.
+func WriteIndex(w io.Writer, baseURL string, files []string, lastMod time.Time) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header + `<sitemapindex xmlns="` + xmlns + `">` + "\n")
+	for _, name := range files {
+		bw.WriteString("<sitemap><loc>")
+		xml.EscapeText(bw, []byte(strings.TrimSuffix(baseURL, "/")+"/"+name))
+		bw.WriteString("</loc><lastmod>" + lastMod.UTC().Format(time.RFC3339) + "</lastmod></sitemap>\n")
+	}
+	bw.WriteString("</sitemapindex>\n")
+
+	return bw.Flush()
+}
+
+// DirCreator creates files in dir, replacing existing ones. Each file is written to a
+// temporary name and renamed on Close, so a sitemap being served is never seen half
+// written.
+func DirCreator(dir string) func(name string) (io.WriteCloser, error) {
+	return func(name string) (io.WriteCloser, error) {
+		f, err := os.CreateTemp(dir, "."+name+".*")
+		if err != nil {
+			return nil, err
+		}
+		return &renameOnClose{File: f, path: filepath.Join(dir, name)}, nil
+	}
+}
+
+type renameOnClose struct {
+	*os.File
+	path string
+	// deferred leaves the rename to commit, so Generate can swap in a set of files
+	// together.
+	deferred bool
+}
+
+func (f *renameOnClose) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	if f.deferred {
+		return nil
+	}
+
+	return f.commit()
+}
+
+func (f *renameOnClose) commit() error {
+	return os.Rename(f.Name(), f.path)
+}
+
+// abort removes the temporary file, closing it first if it is still open.
+func (f *renameOnClose) abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
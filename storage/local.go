@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LocalOptions struct {
+	// Root is the directory objects are stored under.
+	Root string
+	// BaseURL is where Handler is mounted, e.g. "http://localhost:4000/files". It is
+	// used to build signed URLs.
+	BaseURL string
+	// SigningKey authenticates signed URLs.
+	SigningKey []byte
+}
+
+// LocalStore keeps objects as files under a directory, for development and tests.
+type LocalStore struct {
+	opts LocalOptions
+}
+
+func NewLocalStore(opts LocalOptions) (*LocalStore, error) {
+	if err := os.MkdirAll(opts.Root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{opts: opts}, nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.opts.Root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, _ PutOptions) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return f, s.info(key, st), nil
+}
+
+func (s *LocalStore) info(key string, st fs.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         st.Size(),
+		ContentType:  mime.TypeByExtension(filepath.Ext(key)),
+		LastModified: st.ModTime(),
+	}
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *LocalStore) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	err := filepath.WalkDir(s.opts.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.opts.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		st, err := d.Info()
+		if err != nil {
+			return err
+		}
+		out = append(out, *s.info(key, st))
+		return nil
+	})
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, err
+}
+
+func (s *LocalStore) sign(method, key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.opts.SigningKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d", method, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL returns a URL served by Handler. SigningKey must be set.
+func (s *LocalStore) SignedURL(_ context.Context, key, method string, expires time.Duration) (string, error) {
+	if len(s.opts.SigningKey) == 0 {
+		return "", errors.New("storage: LocalStore has no SigningKey")
+	}
+	if !validMethod(method) {
+		return "", fmt.Errorf("storage: cannot sign %s requests", method)
+	}
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	exp := time.Now().Add(expires).Unix()
+	q := url.Values{
+		"method":  {method},
+		"expires": {strconv.FormatInt(exp, 10)},
+		"sig":     {s.sign(method, key, exp)},
+	}
+
+	return strings.TrimRight(s.opts.BaseURL, "/") + "/" + key + "?" + q.Encode(), nil
+}
+
+// Handler serves signed GET and PUT requests for objects. Mount it with
+// http.StripPrefix so the remaining path is the object key.
+func (s *LocalStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		q := r.URL.Query()
+
+		exp, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+		valid := err == nil && time.Now().Unix() <= exp && q.Get("method") == r.Method &&
+			hmac.Equal([]byte(q.Get("sig")), []byte(s.sign(r.Method, key, exp)))
+		if !valid {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rc, info, err := s.Get(r.Context(), key)
+			if errors.Is(err, ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			defer rc.Close()
+			if info.ContentType != "" {
+				w.Header().Set("Content-Type", info.ContentType)
+			}
+			http.ServeContent(w, r, key, info.LastModified, rc.(io.ReadSeeker))
+		case http.MethodPut:
+			if err := s.Put(r.Context(), key, r.Body, PutOptions{}); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
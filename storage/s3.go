@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hasahmad/go-helpers/internal/awsv4"
+)
+
+type S3Options struct {
+	Bucket string
+	Region string
+	// Endpoint overrides the AWS endpoint, e.g. "http://localhost:9000" for MinIO.
+	// Defaults to https://s3.<region>.amazonaws.com.
+	Endpoint string
+	// PathStyle addresses the bucket as <endpoint>/<bucket>/<key> instead of
+	// <bucket>.<endpoint>/<key>. MinIO needs this.
+	PathStyle   bool
+	Credentials awsv4.Credentials
+	Client      *http.Client
+}
+
+// S3Store stores objects in an S3 compatible bucket.
+type S3Store struct {
+	opts     S3Options
+	endpoint *url.URL
+}
+
+// NewS3Store returns an S3Store. Credentials default to the AWS_* environment
+// variables.
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("storage: S3 bucket is required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	if opts.Credentials.AccessKeyID == "" {
+		opts.Credentials = awsv4.CredentialsFromEnv()
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	u, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Store{opts: opts, endpoint: u}, nil
+}
+
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := "/" + key
+	if s.opts.PathStyle {
+		path = strings.TrimSuffix("/"+s.opts.Bucket+path, "/")
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = u.Path + path
+	u.RawPath = s.endpoint.EscapedPath() + awsv4.EscapePath(path)
+
+	return &u
+}
+
+func (s *S3Store) do(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	awsv4.Sign(req, awsv4.HashPayload(body), s.opts.Credentials, s.opts.Region, "s3", time.Now())
+	return s.opts.Client.Do(req)
+}
+
+// Put uploads r. The body is buffered in memory so it can be signed; use SignedURL
+// with PUT to let clients upload large files directly.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+
+	res, err := s.do(ctx, http.MethodPut, s.objectURL(key), body, header)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkResponse(res)
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := s.do(ctx, http.MethodGet, s.objectURL(key), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkResponse(res); err != nil {
+		res.Body.Close()
+		return nil, nil, err
+	}
+
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+		ETag:        strings.Trim(res.Header.Get("ETag"), `"`),
+	}
+	info.LastModified, _ = http.ParseTime(res.Header.Get("Last-Modified"))
+
+	return res.Body, info, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodDelete, s.objectURL(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := checkResponse(res); err != nil && err != ErrNotFound {
+		return err
+	}
+
+	return nil
+}
+
+func (s *S3Store) SignedURL(_ context.Context, key, method string, expires time.Duration) (string, error) {
+	if !validMethod(method) {
+		return "", fmt.Errorf("storage: cannot sign %s requests", method)
+	}
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	u := awsv4.Presign(method, s.objectURL(key), s.opts.Credentials, s.opts.Region, "s3", time.Now(), expires)
+	return u.String(), nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 until every object under prefix is returned.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	token := ""
+	for {
+		u := s.objectURL("")
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = q.Encode()
+
+		res, err := s.do(ctx, http.MethodGet, u, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page listBucketResult
+		err = checkResponse(res)
+		if err == nil {
+			err = xml.NewDecoder(res.Body).Decode(&page)
+		}
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range page.Contents {
+			out = append(out, ObjectInfo{
+				Key:          c.Key,
+				Size:         c.Size,
+				ETag:         strings.Trim(c.ETag, `"`),
+				LastModified: c.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return out, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// S3Error is returned for unexpected S3 responses.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return "storage: s3 " + strconv.Itoa(e.StatusCode) + " " + e.Code + ": " + e.Message
+}
+
+func checkResponse(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	e := &S3Error{StatusCode: res.StatusCode}
+	_ = xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(e)
+	return e
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+type PutOptions struct {
+	ContentType string
+	// Size is the content length if known. Unknown sizes (-1 or 0 with a non-empty
+	// reader) make some implementations buffer the body.
+	Size int64
+}
+
+// BlobStore is implemented by the S3 and local filesystem stores so upload handlers
+// work the same in development and production.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a time-limited URL allowing method (GET or PUT) on key
+	// without further credentials.
+	SignedURL(ctx context.Context, key, method string, expires time.Duration) (string, error)
+	// List returns the objects whose keys start with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// CleanKey validates an object key: slash separated, no empty, "." or ".." segments,
+// no leading slash and no backslashes.
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+
+	return path.Clean(key), nil
+}
+
+func validMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut
+}
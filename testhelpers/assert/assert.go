@@ -0,0 +1,107 @@
+// Package assert has a few small test assertions that print a diff on failure, so
+// tests stay readable without depending on testify.
+package assert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Equal reports an error when got and want are not deeply equal.
+func Equal(t testing.TB, got, want interface{}) {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return
+	}
+
+	t.Errorf("values are not equal (-want +got):\n%s", Diff(format(want), format(got)))
+}
+
+// NilError stops the test when err is not nil.
+func NilError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// ErrorIs reports an error when err does not wrap target.
+func ErrorIs(t testing.TB, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("error %v is not %v", err, target)
+	}
+}
+
+// StatusCode reports an error when the recorded status is not want, including the
+// start of the body to show what went wrong.
+func StatusCode(t testing.TB, rr *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rr.Code == want {
+		return
+	}
+
+	body := rr.Body.String()
+	if len(body) > 500 {
+		body = body[:500] + "..."
+	}
+	t.Errorf("status code = %d, want %d; body: %s", rr.Code, want, body)
+}
+
+// JSONEqual reports an error when the two JSON documents differ, ignoring formatting
+// and object key order.
+func JSONEqual(t testing.TB, got, want string) {
+	t.Helper()
+
+	g, err := normalizeJSON(got)
+	if err != nil {
+		t.Errorf("got is not valid JSON: %v\n%s", err, got)
+		return
+	}
+	w, err := normalizeJSON(want)
+	if err != nil {
+		t.Errorf("want is not valid JSON: %v\n%s", err, want)
+		return
+	}
+	if g != w {
+		t.Errorf("JSON is not equal (-want +got):\n%s", Diff(w, g))
+	}
+}
+
+func normalizeJSON(s string) (string, error) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func format(v interface{}) string {
+	switch v.(type) {
+	case string, []byte:
+		return fmt.Sprintf("%s\n", v)
+	}
+
+	if js, err := json.MarshalIndent(v, "", "  "); err == nil && reflect.ValueOf(v).Kind() != reflect.Func {
+		return fmt.Sprintf("%T %s\n", v, js)
+	}
+
+	return fmt.Sprintf("%#v\n", v)
+}
@@ -0,0 +1,270 @@
+// Package dbtest is a harness for integration tests against a real database: it
+// connects once per process, applies migrations, loads fixtures and isolates tests
+// with rolled-back transactions or table truncation.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type Options struct {
+	// Driver is the database/sql driver name. The test must import the driver.
+	Driver string
+	// DSN is the connection string. Defaults to $TEST_DATABASE_URL; when both are
+	// empty and Start is nil the test is skipped.
+	DSN string
+	// Start, if set, provisions a database (e.g. by starting a container) and returns
+	// its DSN and a function that tears it down after the tests.
+	Start func() (dsn string, stop func(), err error)
+	// Migrations holds *.sql files applied in name order, skipping *.down.sql. Applied
+	// files are recorded in schema_migrations so reruns don't reapply them.
+	Migrations fs.FS
+	// Tables are truncated by Truncate, in order.
+	Tables []string
+	// Placeholder returns the bind parameter for the n-th (1-based) argument.
+	// Defaults to "?"; use Dollar for PostgreSQL.
+	Placeholder func(n int) string
+}
+
+// Dollar is the PostgreSQL placeholder style: $1, $2, ...
+func Dollar(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// DB is a migrated test database shared by every test in the process.
+type DB struct {
+	*sql.DB
+	opts Options
+}
+
+var (
+	mu  sync.Mutex
+	dbs = map[string]*DB{}
+)
+
+// New returns the shared database for opts, connecting and migrating on first use.
+func New(t testing.TB, opts Options) *DB {
+	t.Helper()
+	if opts.Placeholder == nil {
+		opts.Placeholder = func(int) string { return "?" }
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := opts.Driver + " " + opts.DSN
+	if db, ok := dbs[key]; ok {
+		return db
+	}
+
+	dsn := opts.DSN
+	if dsn == "" {
+		dsn = os.Getenv("TEST_DATABASE_URL")
+	}
+	if dsn == "" && opts.Start != nil {
+		var stop func()
+		var err error
+		dsn, stop, err = opts.Start()
+		if err != nil {
+			t.Fatalf("dbtest: starting database: %v", err)
+		}
+		// There is no process-wide teardown hook outside TestMain, so the database
+		// lives until Close is called or the process exits.
+		registerStop(stop)
+	}
+	if dsn == "" {
+		t.Skip("dbtest: TEST_DATABASE_URL is not set")
+	}
+
+	sqlDB, err := sql.Open(opts.Driver, dsn)
+	if err != nil {
+		t.Fatalf("dbtest: opening database: %v", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		t.Fatalf("dbtest: connecting to database: %v", err)
+	}
+
+	db := &DB{DB: sqlDB, opts: opts}
+	if opts.Migrations != nil {
+		if err := db.migrate(context.Background()); err != nil {
+			sqlDB.Close()
+			t.Fatalf("dbtest: %v", err)
+		}
+	}
+
+	dbs[key] = db
+	return db
+}
+
+var stops []func()
+
+func registerStop(stop func()) {
+	if stop != nil {
+		stops = append(stops, stop)
+	}
+}
+
+// Close closes every shared database and stops any started by Options.Start. Call it
+// from TestMain after m.Run.
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for key, db := range dbs {
+		db.DB.Close()
+		delete(dbs, key)
+	}
+	for _, stop := range stops {
+		stop()
+	}
+	stops = nil
+}
+
+func (db *DB) migrate(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(255) PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(db.opts.Migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+
+		var n int
+		row := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = "+db.opts.Placeholder(1), name)
+		if err := row.Scan(&n); err != nil {
+			return fmt.Errorf("checking migration %s: %w", name, err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		script, err := fs.ReadFile(db.opts.Migrations, name)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(script)); err != nil {
+			return fmt.Errorf("applying migration %s: %w", name, err)
+		}
+		if _, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ("+db.opts.Placeholder(1)+")", name); err != nil {
+			return fmt.Errorf("recording migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Tx begins a transaction that is rolled back when the test ends, so whatever the
+// test writes is discarded. Code under test must accept the *sql.Tx (or an interface
+// it satisfies) for this to isolate it.
+func (db *DB) Tx(t testing.TB) *sql.Tx {
+	t.Helper()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("dbtest: beginning transaction: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	return tx
+}
+
+// Truncate empties Options.Tables now and again when the test ends, for code that
+// manages its own transactions.
+func (db *DB) Truncate(t testing.TB) {
+	t.Helper()
+
+	truncate := func() error {
+		for _, table := range db.opts.Tables {
+			stmt := "DELETE FROM " + table
+			if db.opts.Driver == "postgres" || db.opts.Driver == "pgx" {
+				stmt = "TRUNCATE " + table + " RESTART IDENTITY CASCADE"
+			}
+			if _, err := db.ExecContext(context.Background(), stmt); err != nil {
+				return fmt.Errorf("truncating %s: %w", table, err)
+			}
+		}
+		return nil
+	}
+
+	if err := truncate(); err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := truncate(); err != nil {
+			t.Errorf("dbtest: %v", err)
+		}
+	})
+}
+
+// Execer is satisfied by *sql.DB, *sql.Tx and *sql.Conn.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// LoadFixtures runs fixture files against ex, usually the transaction from Tx. .sql
+// files are executed as is; .yml and .yaml files hold rows per table (see
+// ParseFixtures).
+func (db *DB) LoadFixtures(t testing.TB, ex Execer, fsys fs.FS, names ...string) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatalf("dbtest: reading fixture %s: %v", name, err)
+		}
+
+		switch path.Ext(name) {
+		case ".sql":
+			if _, err := ex.ExecContext(ctx, string(data)); err != nil {
+				t.Fatalf("dbtest: loading fixture %s: %v", name, err)
+			}
+		case ".yml", ".yaml":
+			tables, err := ParseFixtures(data)
+			if err != nil {
+				t.Fatalf("dbtest: parsing fixture %s: %v", name, err)
+			}
+			for _, table := range tables {
+				for _, row := range table.Rows {
+					query, args := db.insert(table.Name, row)
+					if _, err := ex.ExecContext(ctx, query, args...); err != nil {
+						t.Fatalf("dbtest: loading fixture %s into %s: %v", name, table.Name, err)
+					}
+				}
+			}
+		default:
+			t.Fatalf("dbtest: unsupported fixture type %s", name)
+		}
+	}
+}
+
+func (db *DB) insert(table string, row []Column) (string, []interface{}) {
+	cols := make([]string, len(row))
+	marks := make([]string, len(row))
+	args := make([]interface{}, len(row))
+	for i, c := range row {
+		cols[i] = c.Name
+		marks[i] = db.opts.Placeholder(i + 1)
+		args[i] = c.Value
+	}
+
+	return "INSERT INTO " + table + " (" + strings.Join(cols, ", ") + ") VALUES (" + strings.Join(marks, ", ") + ")", args
+}
@@ -0,0 +1,123 @@
+package dbtest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table is the fixture rows for one table, in file order.
+type Table struct {
+	Name string
+	Rows [][]Column
+}
+
+// Column is a single fixture value. Value is nil, a bool, an int64, a float64 or a
+// string.
+type Column struct {
+	Name  string
+	Value interface{}
+}
+
+// ParseFixtures parses the small YAML subset used for fixtures, so no YAML dependency
+// is needed:
+//
+//	users:
+//	  - id: 1
+//	    email: alice@example.com
+//	    activated: true
+//	    bio: null
+//	movies:
+//	  - title: "Casablanca: the movie"
+//
+// Values are scalars only: null or ~, true/false, numbers, and plain, single- or
+// double-quoted strings. Lines starting with # are comments.
+func ParseFixtures(data []byte) ([]Table, error) {
+	var tables []Table
+	var row *[]Column
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimRight(sc.Text(), " \t\r")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if text[0] != ' ' && text[0] != '-' {
+			name := strings.TrimSuffix(trimmed, ":")
+			if name == trimmed || name == "" {
+				return nil, fmt.Errorf("line %d: expected a table name ending in ':'", line)
+			}
+			tables = append(tables, Table{Name: name})
+			row = nil
+			continue
+		}
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("line %d: row outside of a table", line)
+		}
+		t := &tables[len(tables)-1]
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			t.Rows = append(t.Rows, nil)
+			row = &t.Rows[len(t.Rows)-1]
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+		}
+		if row == nil {
+			return nil, fmt.Errorf("line %d: expected '- ' to start a row", line)
+		}
+
+		key, raw, ok := strings.Cut(trimmed, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: expected 'column: value'", line)
+		}
+		val, err := parseScalar(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		*row = append(*row, Column{Name: strings.TrimSpace(key), Value: val})
+	}
+
+	return tables, sc.Err()
+}
+
+func parseScalar(s string) (interface{}, error) {
+	switch {
+	case s == "" || s == "null" || s == "~":
+		return nil, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return parseScalar(strings.TrimSpace(s[:i]))
+	}
+
+	return s, nil
+}
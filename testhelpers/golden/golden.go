@@ -0,0 +1,159 @@
+// Package golden snapshot-tests JSON responses against files under testdata. Run the
+// tests with -update to rewrite the files from the current output.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hasahmad/go-helpers/testhelpers/assert"
+)
+
+var update = registerUpdateFlag()
+
+// registerUpdateFlag defines -update, or shares an -update flag another package has
+// already defined. The flag is read when a comparison runs, after flag.Parse.
+func registerUpdateFlag() func() bool {
+	if f := flag.Lookup("update"); f != nil {
+		return func() bool {
+			if g, ok := f.Value.(flag.Getter); ok {
+				b, _ := g.Get().(bool)
+				return b
+			}
+			return false
+		}
+	}
+
+	b := flag.Bool("update", false, "rewrite golden files")
+	return func() bool { return *b }
+}
+
+// Dir is where golden files are kept, relative to the package under test.
+var Dir = "testdata"
+
+// Scrubber rewrites a decoded JSON value, typically replacing volatile data such as
+// timestamps or generated IDs with a fixed placeholder. It is applied to every value
+// in the document, depth first.
+type Scrubber func(key string, value interface{}) interface{}
+
+var (
+	timestampRX = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+	uuidRX      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Timestamps replaces RFC 3339 timestamp strings with "<timestamp>".
+func Timestamps() Scrubber {
+	return Pattern(timestampRX, "<timestamp>")
+}
+
+// UUIDs replaces UUID strings with "<uuid>".
+func UUIDs() Scrubber {
+	return Pattern(uuidRX, "<uuid>")
+}
+
+// Pattern replaces string values matching re with placeholder.
+func Pattern(re *regexp.Regexp, placeholder string) Scrubber {
+	return func(_ string, v interface{}) interface{} {
+		if s, ok := v.(string); ok && re.MatchString(s) {
+			return placeholder
+		}
+		return v
+	}
+}
+
+// Keys replaces the values of the named object keys, at any depth, with placeholder.
+func Keys(placeholder string, keys ...string) Scrubber {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	return func(key string, v interface{}) interface{} {
+		if set[key] && v != nil {
+			return placeholder
+		}
+		return v
+	}
+}
+
+// AssertJSON compares got with testdata/<name>.json after scrubbing both and
+// normalizing formatting and key order. With -update it writes the file instead.
+func AssertJSON(t testing.TB, got []byte, name string, scrubbers ...Scrubber) {
+	t.Helper()
+
+	normalized, err := normalize(got, scrubbers)
+	if err != nil {
+		t.Fatalf("golden: got is not valid JSON: %v\n%s", err, got)
+	}
+
+	path := filepath.Join(Dir, name+".json")
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, normalized, 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden: %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("golden: %v", err)
+	}
+	if want, err = normalize(want, scrubbers); err != nil {
+		t.Fatalf("golden: %s is not valid JSON: %v", path, err)
+	}
+
+	if !bytes.Equal(normalized, want) {
+		t.Errorf("golden: response differs from %s (-want +got):\n%s", path, assert.Diff(string(want), string(normalized)))
+	}
+}
+
+func normalize(data []byte, scrubbers []Scrubber) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	v = scrub("", v, scrubbers)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func scrub(key string, v interface{}, scrubbers []Scrubber) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = scrub(k, child, scrubbers)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = scrub(key, child, scrubbers)
+		}
+	}
+
+	for _, s := range scrubbers {
+		v = s(key, v)
+	}
+
+	return v
+}
@@ -0,0 +1,101 @@
+package testhelpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NewJSONRequest returns a server-side request for handler tests. body is sent as is
+// when it is a string, []byte or io.Reader and JSON encoded otherwise; nil sends no
+// body. Like httptest.NewRequest it panics on bad input.
+func NewJSONRequest(method, target string, body interface{}) *http.Request {
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	case io.Reader:
+		r = b
+	default:
+		js, err := json.Marshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("testhelpers: encoding request body: %v", err))
+		}
+		r = bytes.NewReader(js)
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	return req
+}
+
+// WithURLParams adds chi URL parameters to r, as the router would for a pattern such
+// as /movies/{id}, so helpers like ReadParam work without routing. kv alternates keys
+// and values.
+func WithURLParams(r *http.Request, kv ...string) *http.Request {
+	if len(kv)%2 != 0 {
+		panic("testhelpers: WithURLParams needs key/value pairs")
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		rctx = chi.NewRouteContext()
+	}
+	for i := 0; i < len(kv); i += 2 {
+		rctx.URLParams.Add(kv[i], kv[i+1])
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// DecodeResponse decodes the recorded JSON body into T.
+func DecodeResponse[T any](rr *httptest.ResponseRecorder) (T, error) {
+	var out T
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+		return out, fmt.Errorf("testhelpers: decoding response %q: %w", truncate(rr.Body.String(), 200), err)
+	}
+
+	return out, nil
+}
+
+// DecodeEnvelope decodes the value under key of an enveloped response such as
+// {"movie": {...}} into T.
+func DecodeEnvelope[T any](rr *httptest.ResponseRecorder, key string) (T, error) {
+	var out T
+	env, err := DecodeResponse[map[string]json.RawMessage](rr)
+	if err != nil {
+		return out, err
+	}
+
+	raw, ok := env[key]
+	if !ok {
+		return out, fmt.Errorf("testhelpers: response has no %q key", key)
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, fmt.Errorf("testhelpers: decoding %q: %w", key, err)
+	}
+
+	return out, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n] + "..."
+}
@@ -0,0 +1,106 @@
+package timeutil
+
+import "time"
+
+// StartOfDay returns midnight at the start of t's day in t's location. Unlike
+// t.Truncate(24*time.Hour) this respects the location and DST transitions.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfDay returns the last nanosecond of t's day in t's location.
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// StartOfWeek returns the start of the week containing t, with weeks beginning on
+// weekStart (time.Monday for ISO weeks).
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return StartOfDay(t).AddDate(0, 0, -offset)
+}
+
+// StartOfMonth returns midnight on the first day of t's month.
+func StartOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfMonth returns the last nanosecond of t's month.
+func EndOfMonth(t time.Time) time.Time {
+	return StartOfMonth(t).AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// DaysBetween returns the number of calendar days from a to b, comparing dates in a's
+// location. It is negative when b is before a and unaffected by DST changes.
+func DaysBetween(a, b time.Time) int {
+	b = b.In(a.Location())
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	// Counting on UTC dates sidesteps 23 and 25 hour days.
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+
+	return int(db.Sub(da) / (24 * time.Hour))
+}
+
+// sameDay reports whether a and b fall on the same date in a's location.
+func sameDay(a, b time.Time) bool {
+	b = b.In(a.Location())
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
+
+// IsBusinessDay reports whether t is a weekday and not one of holidays.
+func IsBusinessDay(t time.Time, holidays []time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	for _, h := range holidays {
+		if sameDay(t, h) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AddBusinessDays moves t forward (or backward for negative n) by n business days,
+// skipping weekends and holidays, keeping t's time of day. With n == 0, t is returned
+// unchanged even if it is not a business day.
+func AddBusinessDays(t time.Time, n int, holidays []time.Time) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if IsBusinessDay(t, holidays) {
+			n--
+		}
+	}
+
+	return t
+}
+
+// BusinessDaysBetween counts business days after a up to and including b.
+func BusinessDaysBetween(a, b time.Time, holidays []time.Time) int {
+	sign := 1
+	if b.Before(a) {
+		a, b, sign = b, a, -1
+	}
+
+	count := 0
+	for d := StartOfDay(a).AddDate(0, 0, 1); !d.After(b); d = d.AddDate(0, 0, 1) {
+		if IsBusinessDay(d, holidays) {
+			count++
+		}
+	}
+
+	return count * sign
+}
@@ -0,0 +1,184 @@
+package timeutil
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidDate = errors.New("must be a date in YYYY-MM-DD format")
+)
+
+// Date is a calendar date without a time of day or location. It marshals to JSON and
+// SQL as "2006-01-02". The zero value is the zero date and marshals as JSON null.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in t's location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// Today returns the current date in loc.
+func Today(loc *time.Location) Date {
+	return DateOf(time.Now().In(loc))
+}
+
+// ParseDate strictly parses a YYYY-MM-DD date, rejecting out-of-range days such as
+// 2023-02-30.
+func ParseDate(s string) (Date, error) {
+	if len(s) != len(DateLayout) {
+		return Date{}, ErrInvalidDate
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, ErrInvalidDate
+	}
+
+	return DateOf(t), nil
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Time returns midnight at the start of d in loc.
+func (d Date) Time(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns d moved by n days, normalizing across months and years.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time(time.UTC).AddDate(0, 0, n))
+}
+
+// AddDate returns d moved by the given years, months and days, like time.AddDate.
+func (d Date) AddDate(years, months, days int) Date {
+	return DateOf(d.Time(time.UTC).AddDate(years, months, days))
+}
+
+func (d Date) Weekday() time.Weekday {
+	return d.Time(time.UTC).Weekday()
+}
+
+// Compare returns -1, 0 or +1 depending on whether d is before, equal to or after o.
+func (d Date) Compare(o Date) int {
+	switch {
+	case d.Year != o.Year:
+		return sign(d.Year - o.Year)
+	case d.Month != o.Month:
+		return sign(int(d.Month) - int(o.Month))
+	}
+
+	return sign(d.Day - o.Day)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
+
+func (d Date) Before(o Date) bool { return d.Compare(o) < 0 }
+func (d Date) After(o Date) bool  { return d.Compare(o) > 0 }
+func (d Date) Equal(o Date) bool  { return d == o }
+
+// DaysUntil returns the number of days from d to o.
+func (d Date) DaysUntil(o Date) int {
+	return int(o.Time(time.UTC).Sub(d.Time(time.UTC)) / (24 * time.Hour))
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts only a quoted YYYY-MM-DD string or null. Timestamps such as
+// "2024-05-01T00:00:00Z" are rejected rather than silently truncated.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Date{}
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return ErrInvalidDate
+	}
+
+	parsed, err := ParseDate(string(data[1 : len(data)-1]))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+
+	return nil
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+
+	return nil
+}
+
+// Scan implements sql.Scanner for DATE columns, which drivers return as time.Time,
+// string or []byte.
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+		return nil
+	case time.Time:
+		*d = DateOf(v)
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(firstN(v, len(DateLayout))))
+	case []byte:
+		return d.UnmarshalText([]byte(firstN(string(v), len(DateLayout))))
+	}
+
+	return fmt.Errorf("timeutil: cannot scan %T into Date", src)
+}
+
+func firstN(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
+
+// Value implements driver.Valuer. The zero date is stored as NULL.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.String(), nil
+}
@@ -0,0 +1,202 @@
+package timeutil
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale holds the words used by TimeAgo and HumanDuration.
+type Locale struct {
+	// Units are singular and plural names for second, minute, hour, day, week, month
+	// and year, in that order.
+	Units [7][2]string
+	// RelativeUnits replace Units inside Ago and FromNow, for languages where the
+	// phrase changes the case, e.g. German "2 Tage" but "vor 2 Tagen". Defaults to
+	// Units.
+	RelativeUnits *[7][2]string
+	// Ago and FromNow are format strings for past and future times, e.g. "%s ago".
+	Ago     string
+	FromNow string
+	JustNow string
+	// And joins the last two parts of a multi-part duration.
+	And string
+	// Plural picks the plural form for n. Defaults to n != 1.
+	Plural func(n int64) bool
+}
+
+var (
+	localesMu sync.RWMutex
+	locales   = map[string]Locale{
+		"en": {
+			Units: [7][2]string{
+				{"second", "seconds"}, {"minute", "minutes"}, {"hour", "hours"},
+				{"day", "days"}, {"week", "weeks"}, {"month", "months"}, {"year", "years"},
+			},
+			Ago: "%s ago", FromNow: "in %s", JustNow: "just now", And: "and",
+		},
+		"de": {
+			Units: [7][2]string{
+				{"Sekunde", "Sekunden"}, {"Minute", "Minuten"}, {"Stunde", "Stunden"},
+				{"Tag", "Tage"}, {"Woche", "Wochen"}, {"Monat", "Monate"}, {"Jahr", "Jahre"},
+			},
+			// "vor" and "in" take the dative.
+			RelativeUnits: &[7][2]string{
+				{"Sekunde", "Sekunden"}, {"Minute", "Minuten"}, {"Stunde", "Stunden"},
+				{"Tag", "Tagen"}, {"Woche", "Wochen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"},
+			},
+			Ago: "vor %s", FromNow: "in %s", JustNow: "gerade eben", And: "und",
+		},
+		"es": {
+			Units: [7][2]string{
+				{"segundo", "segundos"}, {"minuto", "minutos"}, {"hora", "horas"},
+				{"día", "días"}, {"semana", "semanas"}, {"mes", "meses"}, {"año", "años"},
+			},
+			Ago: "hace %s", FromNow: "en %s", JustNow: "ahora mismo", And: "y",
+		},
+		"fr": {
+			Units: [7][2]string{
+				{"seconde", "secondes"}, {"minute", "minutes"}, {"heure", "heures"},
+				{"jour", "jours"}, {"semaine", "semaines"}, {"mois", "mois"}, {"an", "ans"},
+			},
+			Ago: "il y a %s", FromNow: "dans %s", JustNow: "à l'instant", And: "et",
+			Plural: func(n int64) bool { return n > 1 },
+		},
+	}
+)
+
+// RegisterLocale adds or replaces a locale, keyed by language tag such as "pt".
+func RegisterLocale(tag string, l Locale) {
+	localesMu.Lock()
+	defer localesMu.Unlock()
+
+	locales[strings.ToLower(tag)] = l
+}
+
+// lookupLocale finds the locale for a tag like "de-AT", falling back to the base
+// language and then to English.
+func lookupLocale(tag string) Locale {
+	localesMu.RLock()
+	defer localesMu.RUnlock()
+
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if l, ok := locales[tag]; ok {
+		return l
+	}
+	if base, _, ok := strings.Cut(tag, "-"); ok {
+		if l, ok := locales[base]; ok {
+			return l
+		}
+	}
+
+	return locales["en"]
+}
+
+func (l Locale) unit(i int, n int64) string {
+	return l.format(&l.Units, i, n)
+}
+
+func (l Locale) relativeUnit(i int, n int64) string {
+	if l.RelativeUnits != nil {
+		return l.format(l.RelativeUnits, i, n)
+	}
+
+	return l.unit(i, n)
+}
+
+func (l Locale) format(units *[7][2]string, i int, n int64) string {
+	plural := n != 1
+	if l.Plural != nil {
+		plural = l.Plural(n)
+	}
+	if plural {
+		return fmt.Sprintf("%d %s", n, units[i][1])
+	}
+
+	return fmt.Sprintf("%d %s", n, units[i][0])
+}
+
+const (
+	day   = 24 * time.Hour
+	week  = 7 * day
+	month = 30 * day
+	year  = 365 * day
+)
+
+var unitSizes = [7]time.Duration{time.Second, time.Minute, time.Hour, day, week, month, year}
+
+// TimeAgo describes t relative to now in English, e.g. "3 hours ago" or "in 2 days".
+func TimeAgo(t time.Time) string {
+	return TimeAgoLocale(t, time.Now(), "en")
+}
+
+// TimeAgoLocale is TimeAgo relative to now in the given locale. Differences under ten
+// seconds are reported as "just now"; otherwise the largest whole unit is used.
+func TimeAgoLocale(t, now time.Time, locale string) string {
+	l := lookupLocale(locale)
+
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < 10*time.Second {
+		return l.JustNow
+	}
+
+	i := len(unitSizes) - 1
+	for i > 0 && d < unitSizes[i] {
+		i--
+	}
+	phrase := l.relativeUnit(i, int64(d/unitSizes[i]))
+
+	if future {
+		return fmt.Sprintf(l.FromNow, phrase)
+	}
+
+	return fmt.Sprintf(l.Ago, phrase)
+}
+
+// HumanDuration renders d in English with its two most significant units, e.g.
+// "2 hours and 5 minutes".
+func HumanDuration(d time.Duration) string {
+	return HumanDurationLocale(d, "en", 2)
+}
+
+// HumanDurationLocale renders d in locale with at most maxParts units. Durations under
+// a second are rendered as "0 seconds".
+func HumanDurationLocale(d time.Duration, locale string, maxParts int) string {
+	l := lookupLocale(locale)
+	if d < 0 {
+		d = -d
+	}
+	if maxParts < 1 {
+		maxParts = 1
+	}
+
+	// Weeks and months are left out: "1 month and 2 days" tends to read worse than
+	// "32 days" for durations.
+	order := []int{6, 3, 2, 1, 0}
+	var parts []string
+	for _, i := range order {
+		if len(parts) == maxParts {
+			break
+		}
+		n := int64(d / unitSizes[i])
+		if n == 0 {
+			continue
+		}
+		parts = append(parts, l.unit(i, n))
+		d -= time.Duration(n) * unitSizes[i]
+	}
+
+	switch len(parts) {
+	case 0:
+		return l.unit(0, 0)
+	case 1:
+		return parts[0]
+	}
+
+	return strings.Join(parts[:len(parts)-1], ", ") + " " + l.And + " " + parts[len(parts)-1]
+}
@@ -0,0 +1,131 @@
+package timeutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidUnixTime = errors.New("must be a Unix timestamp")
+)
+
+// UnixTime is a time.Time which marshals to JSON as Unix seconds. It unmarshals from
+// a number (fractional seconds allowed), a numeric string or null.
+type UnixTime struct {
+	time.Time
+}
+
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+}
+
+func (t *UnixTime) UnmarshalJSON(data []byte) error {
+	parsed, err := unmarshalEpoch(data, time.Second)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+
+	return nil
+}
+
+// UnixMilliTime is UnixTime with millisecond precision, as JavaScript's Date.now().
+type UnixMilliTime struct {
+	time.Time
+}
+
+func (t UnixMilliTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(strconv.FormatInt(t.UnixMilli(), 10)), nil
+}
+
+func (t *UnixMilliTime) UnmarshalJSON(data []byte) error {
+	parsed, err := unmarshalEpoch(data, time.Millisecond)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+
+	return nil
+}
+
+func unmarshalEpoch(data []byte, unit time.Duration) (time.Time, error) {
+	if bytes.Equal(data, []byte("null")) {
+		return time.Time{}, nil
+	}
+
+	s := strings.Trim(string(data), `"`)
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidUnixTime
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, 0).Add(time.Duration(i) * unit).UTC(), nil
+	}
+
+	return time.Unix(0, int64(f*float64(unit))).UTC(), nil
+}
+
+// FlexibleTime is a time.Time which unmarshals from any format ParseFlexibleTime
+// understands, whether sent as a JSON string or number, and marshals as RFC 3339.
+// Layout records the format the value arrived in.
+type FlexibleTime struct {
+	time.Time
+	Layout string
+}
+
+func (t FlexibleTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(t.Time.Format(time.RFC3339Nano))
+}
+
+func (t *FlexibleTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = FlexibleTime{}
+		return nil
+	}
+
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, layout, err := ParseFlexibleTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time, t.Layout = parsed, layout
+
+	return nil
+}
+
+func (t FlexibleTime) MarshalText() ([]byte, error) {
+	return []byte(t.Time.Format(time.RFC3339Nano)), nil
+}
+
+func (t *FlexibleTime) UnmarshalText(text []byte) error {
+	parsed, layout, err := ParseFlexibleTime(string(text))
+	if err != nil {
+		return err
+	}
+	t.Time, t.Layout = parsed, layout
+
+	return nil
+}
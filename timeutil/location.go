@@ -0,0 +1,59 @@
+package timeutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+type locationKey struct{}
+
+// locations caches loaded zones by name, so the tz database is not read from disk on
+// every request. Only valid names are kept, which bounds it by the database size.
+var locations sync.Map
+
+// ResolveLocation validates an IANA timezone name such as "Europe/Berlin" against the
+// tz database. "UTC" is accepted; empty names and "Local" are rejected since the
+// server's local zone is meaningless to a client. Resolved zones are cached.
+func ResolveLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// WithLocation returns a copy of ctx carrying loc as the request timezone.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFromContext returns the request timezone, or UTC if none was set.
+func LocationFromContext(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+
+	return time.UTC
+}
+
+// InRequestTZ converts t to the request timezone stored in ctx.
+func InRequestTZ(ctx context.Context, t time.Time) time.Time {
+	return t.In(LocationFromContext(ctx))
+}
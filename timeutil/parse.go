@@ -0,0 +1,133 @@
+package timeutil
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LayoutUnix and friends are reported by ParseFlexibleTime for numeric epochs.
+	LayoutUnix      = "unix"
+	LayoutUnixMilli = "unix_ms"
+	LayoutUnixMicro = "unix_us"
+	LayoutUnixNano  = "unix_ns"
+
+	DateLayout = "2006-01-02"
+)
+
+var (
+	ErrUnrecognizedTime = errors.New("unrecognized time format")
+)
+
+// FlexibleLayouts are tried in order by ParseFlexibleTime.
+var FlexibleLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	DateLayout,
+	"20060102T150405Z0700",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+	time.UnixDate,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"02 Jan 2006",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 January 2006",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// ParseFlexibleTime parses s with the first matching layout in FlexibleLayouts, or as a
+// Unix epoch in seconds, milliseconds, microseconds or nanoseconds (chosen by digit
+// count). It returns the matched layout, or one of the LayoutUnix constants. Values
+// without a zone are interpreted as UTC.
+func ParseFlexibleTime(s string) (time.Time, string, error) {
+	return ParseFlexibleTimeIn(s, time.UTC)
+}
+
+// ParseFlexibleTimeIn is ParseFlexibleTime interpreting zone-less values in loc.
+func ParseFlexibleTimeIn(s string, loc *time.Location) (time.Time, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, "", ErrUnrecognizedTime
+	}
+
+	if t, layout, ok := parseEpoch(s); ok {
+		return t, layout, nil
+	}
+
+	for _, layout := range FlexibleLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, layout, nil
+		}
+	}
+
+	return time.Time{}, "", ErrUnrecognizedTime
+}
+
+func parseEpoch(s string) (time.Time, string, bool) {
+	digits := strings.TrimPrefix(s, "-")
+	// Fractional seconds, e.g. 1700000000.123.
+	if whole, frac, ok := strings.Cut(digits, "."); ok && isDigits(whole) && isDigits(frac) && len(whole) <= 10 {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, "", false
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), LayoutUnix, true
+	}
+	// Eight digit values are far more likely to be YYYYMMDD dates than 1970 epochs.
+	if !isDigits(digits) || len(digits) == 8 {
+		return time.Time{}, "", false
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	switch {
+	case len(digits) <= 10:
+		return time.Unix(n, 0).UTC(), LayoutUnix, true
+	case len(digits) <= 13:
+		return time.UnixMilli(n).UTC(), LayoutUnixMilli, true
+	case len(digits) <= 16:
+		return time.UnixMicro(n).UTC(), LayoutUnixMicro, true
+	default:
+		return time.Unix(0, n).UTC(), LayoutUnixNano, true
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
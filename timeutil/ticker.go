@@ -0,0 +1,118 @@
+package timeutil
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/hasahmad/go-helpers/clock"
+)
+
+// jittered returns base randomly adjusted by up to ±fraction of itself.
+func jittered(base time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return base
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	delta := (rand.Float64()*2 - 1) * fraction * float64(base)
+	return base + time.Duration(delta)
+}
+
+// JitterTicker delivers ticks roughly every base, each interval randomly shifted by up
+// to ±jitterFraction of base (0.1 means ±10%), so many instances started together
+// drift apart instead of hitting a backend in lockstep. The channel is closed when
+// ctx is done. Like time.Ticker, ticks are dropped if the receiver falls behind, and
+// it panics if base is not positive.
+func JitterTicker(ctx context.Context, base time.Duration, jitterFraction float64) <-chan time.Time {
+	return JitterTickerClock(ctx, clock.Real, base, jitterFraction)
+}
+
+// JitterTickerClock is JitterTicker driven by c, for tests.
+func JitterTickerClock(ctx context.Context, c clock.Clock, base time.Duration, jitterFraction float64) <-chan time.Time {
+	if base <= 0 {
+		panic("timeutil: non-positive interval for JitterTicker")
+	}
+	c = clock.Or(c)
+	ch := make(chan time.Time, 1)
+
+	go func() {
+		defer close(ch)
+
+		timer := c.NewTimer(jittered(base, jitterFraction))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-timer.C():
+				select {
+				case ch <- t:
+				default:
+				}
+				timer.Reset(jittered(base, jitterFraction))
+			}
+		}
+	}()
+
+	return ch
+}
+
+type ScheduleOptions struct {
+	Interval time.Duration
+	// Jitter is the fraction of Interval by which each run may be shifted.
+	Jitter float64
+	// Immediate runs fn once straight away instead of waiting a full interval.
+	Immediate bool
+	// OnPanic receives recovered panics from fn. The loop carries on either way.
+	OnPanic func(recovered interface{})
+	// Clock is the time source. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// Every runs fn immediately and then every d until ctx is done, recovering panics so
+// one bad run does not kill the loop. Runs never overlap: if fn takes longer than d the
+// next run starts as soon as it returns.
+func Every(ctx context.Context, d time.Duration, fn func(ctx context.Context)) {
+	Schedule(ctx, ScheduleOptions{Interval: d, Immediate: true}, fn)
+}
+
+// Schedule is Every with jitter, optional immediate first run and a panic hook. It
+// blocks until ctx is done.
+func Schedule(ctx context.Context, opts ScheduleOptions, fn func(ctx context.Context)) {
+	run := func() {
+		defer func() {
+			if r := recover(); r != nil && opts.OnPanic != nil {
+				opts.OnPanic(r)
+			}
+		}()
+		fn(ctx)
+	}
+
+	if opts.Immediate {
+		if ctx.Err() != nil {
+			return
+		}
+		run()
+	}
+
+	for range JitterTickerClock(ctx, opts.Clock, opts.Interval, opts.Jitter) {
+		if ctx.Err() != nil {
+			return
+		}
+		run()
+	}
+}
+
+// PanicError wraps a recovered panic value as an error, for OnPanic hooks which log.
+func PanicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+
+	return fmt.Errorf("panic: %v", recovered)
+}
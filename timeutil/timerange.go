@@ -0,0 +1,115 @@
+package timeutil
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRange = errors.New("end must not be before start")
+	ErrEmptyRange   = errors.New("start and end must be set")
+)
+
+// TimeRange is the half-open interval [Start, End).
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// NewTimeRange returns the range from start to end, validated.
+func NewTimeRange(start, end time.Time) (TimeRange, error) {
+	r := TimeRange{Start: start, End: end}
+	return r, r.Validate()
+}
+
+// Validate checks that both ends are set and End is not before Start.
+func (r TimeRange) Validate() error {
+	if r.Start.IsZero() || r.End.IsZero() {
+		return ErrEmptyRange
+	}
+	if r.End.Before(r.Start) {
+		return ErrInvalidRange
+	}
+
+	return nil
+}
+
+func (r TimeRange) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+func (r TimeRange) IsEmpty() bool {
+	return !r.End.After(r.Start)
+}
+
+// Contains reports whether t lies within the range. End is exclusive.
+func (r TimeRange) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// ContainsRange reports whether o lies entirely within r.
+func (r TimeRange) ContainsRange(o TimeRange) bool {
+	return !o.Start.Before(r.Start) && !o.End.After(r.End)
+}
+
+// Overlaps reports whether r and o share any instant. Ranges which merely touch, such
+// as back-to-back bookings, do not overlap.
+func (r TimeRange) Overlaps(o TimeRange) bool {
+	return r.Start.Before(o.End) && o.Start.Before(r.End)
+}
+
+// Intersect returns the overlap of r and o, and false if they do not overlap.
+func (r TimeRange) Intersect(o TimeRange) (TimeRange, bool) {
+	if !r.Overlaps(o) {
+		return TimeRange{}, false
+	}
+
+	out := r
+	if o.Start.After(out.Start) {
+		out.Start = o.Start
+	}
+	if o.End.Before(out.End) {
+		out.End = o.End
+	}
+
+	return out, true
+}
+
+// Split cuts r into consecutive slots of length d. The last slot is shorter if the
+// range is not a multiple of d. A non-positive d returns r unchanged.
+func (r TimeRange) Split(d time.Duration) []TimeRange {
+	if d <= 0 || r.IsEmpty() {
+		return []TimeRange{r}
+	}
+
+	var out []TimeRange
+	for start := r.Start; start.Before(r.End); start = start.Add(d) {
+		end := start.Add(d)
+		if end.After(r.End) {
+			end = r.End
+		}
+		out = append(out, TimeRange{Start: start, End: end})
+	}
+
+	return out
+}
+
+// UnmarshalJSON decodes {"start": ..., "end": ...} and validates the result.
+func (r *TimeRange) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	parsed := TimeRange{Start: raw.Start, End: raw.End}
+	if err := parsed.Validate(); err != nil {
+		return err
+	}
+	*r = parsed
+
+	return nil
+}
@@ -0,0 +1,415 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasahmad/go-helpers/cache"
+	"github.com/hasahmad/go-helpers/clock"
+)
+
+const (
+	storedAtHeader   = "X-Transport-Stored-At"
+	varyHeaderPrefix = "X-Transport-Vary-"
+	// FromCacheHeader is set to "1" on responses served from the cache.
+	FromCacheHeader = "X-From-Cache"
+)
+
+// Store persists serialized responses for the caching transport. Implementations must
+// be safe for concurrent use. RedisStore shares the cache between instances; other
+// shared stores only need to map these three calls onto GET, SET with expiry and DEL.
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore is an in-process Store. Expired entries are dropped lazily on access.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	clock   clock.Clock
+}
+
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(clock.Real)
+}
+
+// NewMemoryStoreWithClock is NewMemoryStore with an explicit time source, for tests.
+func NewMemoryStoreWithClock(c clock.Clock) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), clock: clock.Or(c)}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !e.expires.IsZero() && s.clock.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = s.clock.Now().Add(ttl)
+	}
+	s.entries[key] = e
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+type RedisStoreOptions struct {
+	// Prefix is prepended to every key, e.g. "myapp:http:".
+	Prefix string
+	// Timeout bounds each Redis call, as Store has no context. Defaults to one second.
+	Timeout time.Duration
+}
+
+// RedisStore is a Store backed by Redis through the cache package's client, so cached
+// responses are shared by every instance of a service.
+type RedisStore struct {
+	client cache.RedisClient
+	opts   RedisStoreOptions
+}
+
+// NewRedisStore returns a Store using client, e.g. one from cache.NewRedisClient.
+func NewRedisStore(client cache.RedisClient, opts RedisStoreOptions) *RedisStore {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+
+	return &RedisStore{client: client, opts: opts}
+}
+
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	return s.client.Get(ctx, s.opts.Prefix+key)
+}
+
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	return s.client.Set(ctx, s.opts.Prefix+key, value, ttl)
+}
+
+func (s *RedisStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	return s.client.Del(ctx, s.opts.Prefix+key)
+}
+
+type CacheOptions struct {
+	// Store holds cached responses. Defaults to a new MemoryStore.
+	Store Store
+	// RetainStale is how long responses carrying an ETag or Last-Modified validator are
+	// kept after going stale so they can be revalidated. Defaults to 24 hours.
+	RetainStale time.Duration
+	// MaxBodyBytes is the largest response body that is cached; bigger responses are
+	// passed through untouched. Defaults to 1MB.
+	MaxBodyBytes int64
+	// Clock defaults to the real clock. It does not reach Store; give a MemoryStore the
+	// same clock with NewMemoryStoreWithClock.
+	Clock clock.Clock
+}
+
+// Caching is an http.RoundTripper which caches GET responses according to their
+// Cache-Control, Expires, ETag and Last-Modified headers. Stale responses with a
+// validator are revalidated with If-None-Match / If-Modified-Since and refreshed in
+// place on a 304 Not Modified. Like a shared cache it never stores private
+// responses, nor responses to requests with Authorization unless they are marked
+// public or carry s-maxage.
+type Caching struct {
+	Base http.RoundTripper
+
+	opts CacheOptions
+}
+
+// NewCaching wraps base (http.DefaultTransport if nil) with a response cache.
+func NewCaching(base http.RoundTripper, opts CacheOptions) *Caching {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	if opts.Store == nil {
+		opts.Store = NewMemoryStoreWithClock(opts.Clock)
+	}
+	if opts.RetainStale <= 0 {
+		opts.RetainStale = 24 * time.Hour
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 1 << 20
+	}
+
+	return &Caching{Base: base, opts: opts}
+}
+
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.String()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Caching) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" {
+		return t.Base.RoundTrip(r)
+	}
+
+	key := cacheKey(r)
+	reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok {
+		return t.Base.RoundTrip(r)
+	}
+
+	cached := t.load(key, r)
+	if cached != nil {
+		_, noCache := reqCC["no-cache"]
+		now := t.opts.Clock.Now()
+		if !noCache && freshness(cached, now) > age(cached, now) {
+			stripInternal(cached)
+			cached.Header.Set(FromCacheHeader, "1")
+			return cached, nil
+		}
+
+		etag := cached.Header.Get("ETag")
+		lastModified := cached.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			r = r.Clone(r.Context())
+			if etag != "" {
+				r.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				r.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := t.Base.RoundTrip(r)
+	if err != nil {
+		if cached != nil {
+			cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		for name, values := range resp.Header {
+			cached.Header[name] = values
+		}
+		stripInternal(cached)
+		t.save(key, r, cached)
+		cached.Header.Set(FromCacheHeader, "1")
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		t.save(key, r, resp)
+	}
+
+	return resp, nil
+}
+
+// load reads the cached response for key, provided its Vary headers match r.
+func (t *Caching) load(key string, r *http.Request) *http.Response {
+	raw, ok, err := t.opts.Store.Get(key)
+	if err != nil || !ok {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), r)
+	if err != nil {
+		t.opts.Store.Delete(key)
+		return nil
+	}
+
+	for _, name := range varyHeaders(resp) {
+		if resp.Header.Get(varyHeaderPrefix+name) != r.Header.Get(name) {
+			resp.Body.Close()
+			return nil
+		}
+	}
+
+	return resp
+}
+
+// save stores resp if its headers allow it. The body is buffered so resp stays
+// readable for the caller.
+func (t *Caching) save(key string, r *http.Request, resp *http.Response) {
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return
+	}
+	if _, ok := cc["private"]; ok {
+		return
+	}
+	if r.Header.Get("Authorization") != "" {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		if !public && !sMaxAge {
+			return
+		}
+	}
+	if resp.Header.Get("Vary") == "*" {
+		return
+	}
+	if !t.bufferBody(resp) {
+		return
+	}
+
+	resp.Header.Set(storedAtHeader, strconv.FormatInt(t.opts.Clock.Now().Unix(), 10))
+	for _, name := range varyHeaders(resp) {
+		resp.Header.Set(varyHeaderPrefix+name, r.Header.Get(name))
+	}
+
+	raw, err := httputil.DumpResponse(resp, true)
+	stripInternal(resp)
+	if err != nil {
+		return
+	}
+
+	ttl := freshness(resp, t.opts.Clock.Now())
+	if resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" {
+		ttl += t.opts.RetainStale
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	t.opts.Store.Set(key, raw, ttl)
+}
+
+// bufferBody reads resp's body into memory so it can be stored and still read by the
+// caller. It reports false, leaving the body readable as before, when the body is
+// larger than MaxBodyBytes.
+func (t *Caching) bufferBody(resp *http.Response) bool {
+	if resp.ContentLength > t.opts.MaxBodyBytes {
+		return false
+	}
+
+	body := resp.Body
+	buf, err := io.ReadAll(io.LimitReader(body, t.opts.MaxBodyBytes+1))
+	if err != nil || int64(len(buf)) > t.opts.MaxBodyBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), body), body}
+		return false
+	}
+	body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+
+	return true
+}
+
+// stripInternal removes the cache's bookkeeping headers before a response is handed
+// back to the caller.
+func stripInternal(resp *http.Response) {
+	resp.Header.Del(storedAtHeader)
+	for _, name := range varyHeaders(resp) {
+		resp.Header.Del(varyHeaderPrefix + name)
+	}
+}
+
+func varyHeaders(resp *http.Response) []string {
+	var names []string
+	for _, v := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+func parseCacheControl(v string) map[string]string {
+	cc := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return cc
+}
+
+// freshness returns how long resp may be served without revalidation.
+func freshness(resp *http.Response, now time.Time) time.Duration {
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if expires := resp.Header.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return exp.Sub(now)
+		}
+		return exp.Sub(date)
+	}
+
+	return 0
+}
+
+// age returns how long ago resp was stored.
+func age(resp *http.Response, now time.Time) time.Duration {
+	secs, err := strconv.ParseInt(resp.Header.Get(storedAtHeader), 10, 64)
+	if err != nil {
+		return time.Duration(1<<63 - 1)
+	}
+
+	return now.Sub(time.Unix(secs, 0))
+}
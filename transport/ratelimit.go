@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hasahmad/go-helpers/clock"
+	"github.com/hasahmad/go-helpers/ratelimit"
+)
+
+var (
+	ErrQueueFull = errors.New("transport: too many requests waiting for host")
+)
+
+type RateLimitOptions struct {
+	// RequestsPerSecond is the sustained request rate allowed per host. Zero disables
+	// rate limiting.
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent back to back. Defaults to 1.
+	Burst int
+	// MaxConcurrent caps in-flight requests per host. Zero means unlimited.
+	MaxConcurrent int
+	// MaxQueue caps the number of requests waiting for a host. Once reached, further
+	// requests fail with ErrQueueFull. Zero means unlimited.
+	MaxQueue int
+	// KeyFunc groups requests into buckets. Defaults to the request host.
+	KeyFunc func(r *http.Request) string
+	// Clock is the time source. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+type hostLimiter struct {
+	limiter *ratelimit.Limiter
+	slots   chan struct{}
+	waiting int64
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// RateLimited is an http.RoundTripper which enforces per-host request rates and
+// concurrency limits. Requests over the limit wait for their turn until the request
+// context is done.
+type RateLimited struct {
+	Base http.RoundTripper
+
+	opts  RateLimitOptions
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// NewRateLimited wraps base (http.DefaultTransport if nil) with per-host limits.
+func NewRateLimited(base http.RoundTripper, opts RateLimitOptions) *RateLimited {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.Burst < 1 {
+		opts.Burst = 1
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = func(r *http.Request) string {
+			return r.URL.Host
+		}
+	}
+
+	return &RateLimited{
+		Base:  base,
+		opts:  opts,
+		hosts: make(map[string]*hostLimiter),
+	}
+}
+
+func (t *RateLimited) host(key string) *hostLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.hosts[key]
+	if !ok {
+		h = &hostLimiter{}
+		if t.opts.RequestsPerSecond > 0 {
+			h.limiter = ratelimit.NewWithClock(t.opts.RequestsPerSecond, t.opts.Burst, t.opts.Clock)
+		}
+		if t.opts.MaxConcurrent > 0 {
+			h.slots = make(chan struct{}, t.opts.MaxConcurrent)
+		}
+		t.hosts[key] = h
+	}
+
+	return h
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RateLimited) RoundTrip(r *http.Request) (*http.Response, error) {
+	h := t.host(t.opts.KeyFunc(r))
+
+	release, err := t.acquire(r.Context(), h)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Base.RoundTrip(r)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		now := t.opts.Clock.Now()
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+			h.mu.Lock()
+			if until := now.Add(d); until.After(h.pausedUntil) {
+				h.pausedUntil = until
+			}
+			h.mu.Unlock()
+		}
+	}
+
+	// Hold the concurrency slot until the body has been consumed.
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+func (t *RateLimited) acquire(ctx context.Context, h *hostLimiter) (func(), error) {
+	if t.opts.MaxQueue > 0 {
+		if atomic.AddInt64(&h.waiting, 1) > int64(t.opts.MaxQueue) {
+			atomic.AddInt64(&h.waiting, -1)
+			return nil, ErrQueueFull
+		}
+		defer atomic.AddInt64(&h.waiting, -1)
+	}
+
+	h.mu.Lock()
+	pause := h.pausedUntil.Sub(t.opts.Clock.Now())
+	h.mu.Unlock()
+	if pause > 0 {
+		timer := t.opts.Clock.NewTimer(pause)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}
+
+	if h.limiter != nil {
+		if err := h.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+
+	return 0, false
+}
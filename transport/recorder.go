@@ -0,0 +1,283 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	ErrInteractionNotFound = errors.New("transport: no recorded interaction matches request")
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves every request from the cassette and fails on unknown requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request upstream and records it, replacing the cassette.
+	ModeRecord
+	// ModeReplayOrRecord replays known requests and records the rest.
+	ModeReplayOrRecord
+)
+
+type RecordedRequest struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body,omitempty"`
+	Encoding string      `json:"encoding,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Encoding   string      `json:"encoding,omitempty"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the on-disk collection of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher reports whether a live request matches a recorded one. body is the live
+// request body.
+type Matcher func(r *http.Request, body []byte, rec RecordedRequest) bool
+
+// DefaultMatcher matches on method, full URL and body.
+func DefaultMatcher(r *http.Request, body []byte, rec RecordedRequest) bool {
+	if r.Method != rec.Method || r.URL.String() != rec.URL {
+		return false
+	}
+	recBody, err := decodeBody(rec.Body, rec.Encoding)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(body, recBody)
+}
+
+// MethodURLMatcher matches on method and full URL only.
+func MethodURLMatcher(r *http.Request, _ []byte, rec RecordedRequest) bool {
+	return r.Method == rec.Method && r.URL.String() == rec.URL
+}
+
+// MethodPathMatcher matches on method, path and query, ignoring scheme and host. Useful
+// when recordings are made against httptest servers on random ports.
+func MethodPathMatcher(r *http.Request, _ []byte, rec RecordedRequest) bool {
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+
+	return r.Method == rec.Method && r.URL.Path == u.Path && r.URL.RawQuery == u.RawQuery
+}
+
+type RecorderOptions struct {
+	Mode Mode
+	// Base performs real requests when recording. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Matcher selects the recorded interaction for a request. Defaults to DefaultMatcher.
+	Matcher Matcher
+	// RedactHeaders are replaced with "REDACTED" in recorded requests and responses.
+	// Defaults to Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+}
+
+// Recorder is an http.RoundTripper which records outbound requests to a cassette file
+// and replays them deterministically. Identical requests are replayed in the order they
+// were recorded.
+type Recorder struct {
+	path string
+	opts RecorderOptions
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder loads the cassette at path. A missing file is treated as an empty cassette
+// except in ModeReplay.
+func NewRecorder(path string, opts RecorderOptions) (*Recorder, error) {
+	if opts.Base == nil {
+		opts.Base = http.DefaultTransport
+	}
+	if opts.Matcher == nil {
+		opts.Matcher = DefaultMatcher
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+	}
+
+	rec := &Recorder{path: path, opts: opts}
+	if opts.Mode == ModeRecord {
+		return rec, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && opts.Mode == ModeReplayOrRecord:
+		return rec, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &rec.cassette); err != nil {
+		return nil, fmt.Errorf("transport: invalid cassette %s: %w", path, err)
+	}
+	rec.used = make([]bool, len(rec.cassette.Interactions))
+
+	return rec, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rec *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rec.opts.Mode != ModeRecord {
+		if resp, ok := rec.replay(r, body); ok {
+			return resp, nil
+		}
+		if rec.opts.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, r.Method, r.URL)
+		}
+	}
+
+	out := r.Clone(r.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := rec.opts.Base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	reqEnc, reqBody := encodeBody(body)
+	respEnc, respBodyStr := encodeBody(respBody)
+	rec.mu.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method:   r.Method,
+			URL:      r.URL.String(),
+			Header:   rec.redact(r.Header),
+			Body:     reqBody,
+			Encoding: reqEnc,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     rec.redact(resp.Header),
+			Body:       respBodyStr,
+			Encoding:   respEnc,
+		},
+	})
+	rec.used = append(rec.used, true)
+	rec.mu.Unlock()
+
+	return resp, nil
+}
+
+func (rec *Recorder) replay(r *http.Request, body []byte) (*http.Response, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	for i, in := range rec.cassette.Interactions {
+		if rec.used[i] || !rec.opts.Matcher(r, body, in.Request) {
+			continue
+		}
+
+		respBody, err := decodeBody(in.Response.Body, in.Response.Encoding)
+		if err != nil {
+			continue
+		}
+		rec.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       r,
+		}, true
+	}
+
+	return nil, false
+}
+
+func (rec *Recorder) redact(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range rec.opts.RedactHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, "REDACTED")
+		}
+	}
+
+	return h
+}
+
+// Save writes the cassette to disk. It is a no-op in ModeReplay.
+func (rec *Recorder) Save() error {
+	if rec.opts.Mode == ModeReplay {
+		return nil
+	}
+
+	rec.mu.Lock()
+	data, err := json.MarshalIndent(rec.cassette, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rec.path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(rec.path, data, 0o644)
+}
+
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return "", string(b)
+	}
+
+	return "base64", base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeBody(s, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(s)
+	}
+
+	return []byte(s), nil
+}
@@ -0,0 +1,49 @@
+package upload
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SanitizeFilename makes a client-supplied filename safe to store or echo back: any
+// directory part is dropped (with / or \ separators), characters other than letters,
+// digits, spaces, dots, dashes and underscores become underscores, leading dots are
+// removed so the file is not hidden and the name is capped at 255 bytes keeping the
+// extension. It returns "file" when nothing is left.
+func SanitizeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '.', r == '-', r == '_', r == ' ':
+			return r
+		case unicode.IsControl(r):
+			return -1
+		}
+		return '_'
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "file"
+	}
+
+	const maxLen = 255
+	if len(name) > maxLen {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:maxLen-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+
+	return name
+}
@@ -0,0 +1,349 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("upload: unsupported image format")
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// EncodeFunc encodes img at the given quality (1-100, ignored by lossless formats).
+type EncodeFunc func(w io.Writer, img image.Image, quality int) error
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]EncodeFunc{
+		FormatJPEG: func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		FormatPNG: func(w io.Writer, img image.Image, _ int) error {
+			return png.Encode(w, img)
+		},
+	}
+)
+
+// RegisterEncoder adds an output format. The standard library has no WebP encoder, so
+// FormatWebP is only available once one is registered, e.g. from a cgo libwebp
+// binding. WebP input likewise needs a decoder registered with the image package
+// (golang.org/x/image/webp).
+func RegisterEncoder(format string, fn EncodeFunc) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = fn
+}
+
+type ResizeOptions struct {
+	// MaxWidth and MaxHeight bound the output, preserving the aspect ratio. Images are
+	// never upscaled. Zero means unbounded.
+	MaxWidth  int
+	MaxHeight int
+	// Format is the output format. Defaults to the input format when it has an
+	// encoder, otherwise JPEG.
+	Format string
+	// Quality is the JPEG/WebP quality. Defaults to 85.
+	Quality int
+	// MaxBytes limits how much input is read. Defaults to 32MB.
+	MaxBytes int64
+	// MaxPixels rejects inputs above width*height before decoding them, bounding
+	// memory use. Defaults to 40 megapixels.
+	MaxPixels int64
+}
+
+// ResizedImage is the result of ResizeImage.
+type ResizedImage struct {
+	Data        []byte
+	ContentType string
+	Format      string
+	Width       int
+	Height      int
+}
+
+// ResizeImage decodes an image, applies its EXIF orientation, scales it down to fit
+// the max dimensions and re-encodes it. Only JPEG and PNG are built in; WebP is out of
+// scope as the standard library cannot read or write it, so FormatWebP returns
+// ErrUnsupportedFormat until an encoder is registered with RegisterEncoder.
+func ResizeImage(r io.Reader, opts ResizeOptions) (*ResizedImage, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 32 << 20
+	}
+	if opts.MaxPixels <= 0 {
+		opts.MaxPixels = 40_000_000
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = 85
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	cfg, inFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > opts.MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatJPEG
+		if encoder(inFormat) != nil {
+			format = inFormat
+		}
+	}
+	encode := encoder(format)
+	if encode == nil {
+		return nil, fmt.Errorf("%w: no encoder for %s", ErrUnsupportedFormat, format)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	orientation := 1
+	if inFormat == FormatJPEG {
+		orientation = exifOrientation(data)
+	}
+
+	// Orientations 5-8 rotate by 90 degrees, so the bounds apply to swapped sides.
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	maxW, maxH := opts.MaxWidth, opts.MaxHeight
+	if orientation >= 5 {
+		maxW, maxH = maxH, maxW
+	}
+	tw, th := fitWithin(w, h, maxW, maxH)
+
+	out := toRGBA(src)
+	if tw != w || th != h {
+		out = scaleDown(out, tw, th)
+	}
+	out = orient(out, orientation)
+
+	var buf bytes.Buffer
+	if err := encode(&buf, out, opts.Quality); err != nil {
+		return nil, err
+	}
+
+	return &ResizedImage{
+		Data:        buf.Bytes(),
+		ContentType: "image/" + format,
+		Format:      format,
+		Width:       out.Bounds().Dx(),
+		Height:      out.Bounds().Dy(),
+	}, nil
+}
+
+func encoder(format string) EncodeFunc {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	return encoders[format]
+}
+
+func fitWithin(w, h, maxW, maxH int) (int, int) {
+	scale := 1.0
+	if maxW > 0 && w > maxW {
+		scale = float64(maxW) / float64(w)
+	}
+	if maxH > 0 && h > maxH {
+		if s := float64(maxH) / float64(h); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1 {
+		return w, h
+	}
+
+	tw, th := int(float64(w)*scale+0.5), int(float64(h)*scale+0.5)
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	return tw, th
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if m, ok := src.(*image.RGBA); ok && m.Bounds().Min == (image.Point{}) {
+		return m
+	}
+
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// scaleDown resizes with an area-averaging box filter, which gives clean results for
+// downscaling without ringing.
+func scaleDown(src *image.RGBA, tw, th int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+
+	for y := 0; y < th; y++ {
+		y0 := y * sh / th
+		y1 := (y + 1) * sh / th
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < tw; x++ {
+			x0 := x * sw / tw
+			x1 := (x + 1) * sw / tw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) so the image displays upright.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontal
+				dx, dy = w-1-x, y
+			case 3: // rotate 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+
+	return dst
+}
+
+// exifOrientation returns the orientation tag from a JPEG's APP1 Exif segment, or 1.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	// Compare as uint32 so a huge offset cannot wrap negative on 32-bit platforms.
+	off := order.Uint32(t[4:])
+	if off > uint32(len(t)-2) {
+		return 1
+	}
+	ifd := int(off)
+	count := int(order.Uint16(t[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(t) {
+			return 1
+		}
+		if order.Uint16(t[entry:]) == 0x0112 {
+			if v := int(order.Uint16(t[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,181 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// TempFile is a temporary file that removes itself when its context is done or
+// Cleanup is called, whichever comes first.
+type TempFile struct {
+	*os.File
+	Size int64
+	once sync.Once
+	err  error
+}
+
+// TempFileFromReader copies r into a new temporary file, failing with ErrTooLarge
+// after maxSize bytes (zero means unlimited). The file is positioned at the start.
+//
+// Pass the request context so the file is removed when the handler returns:
+//
+//	f, err := upload.TempFileFromReader(r.Context(), part, 50<<20)
+func TempFileFromReader(ctx context.Context, r io.Reader, maxSize int64) (*TempFile, error) {
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	t := &TempFile{File: f}
+
+	if err := t.fill(r, maxSize); err != nil {
+		t.Cleanup()
+		return nil, err
+	}
+
+	cleanupOnDone(ctx, t.Cleanup)
+	return t, nil
+}
+
+func (t *TempFile) fill(r io.Reader, maxSize int64) error {
+	src := r
+	if maxSize > 0 {
+		src = io.LimitReader(r, maxSize+1)
+	}
+
+	n, err := io.Copy(t.File, src)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 && n > maxSize {
+		return ErrTooLarge
+	}
+	t.Size = n
+
+	_, err = t.File.Seek(0, io.SeekStart)
+	return err
+}
+
+// Cleanup closes and removes the file. It is safe to call more than once.
+func (t *TempFile) Cleanup() error {
+	t.once.Do(func() {
+		t.File.Close()
+		t.err = os.Remove(t.File.Name())
+		if errors.Is(t.err, os.ErrNotExist) {
+			t.err = nil
+		}
+	})
+
+	return t.err
+}
+
+func cleanupOnDone(ctx context.Context, cleanup func() error) {
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		cleanup()
+	}()
+}
+
+type SpoolOptions struct {
+	// MemoryLimit is how much is kept in memory before spilling to a temporary file.
+	// Defaults to 1MB.
+	MemoryLimit int64
+	// MaxSize fails with ErrTooLarge past this many bytes. Zero means unlimited.
+	MaxSize int64
+	// Dir is where spilled files are created. Defaults to os.TempDir().
+	Dir string
+}
+
+// SpooledFile holds data in memory while it is small and on disk once it grows, so
+// large multipart parts can be re-read without exhausting memory.
+type SpooledFile struct {
+	mem  *bytes.Reader
+	file *TempFile
+	size int64
+}
+
+// Spool reads r fully into a SpooledFile. Any spilled file is removed when ctx is done
+// or Close is called.
+func Spool(ctx context.Context, r io.Reader, opts SpoolOptions) (*SpooledFile, error) {
+	if opts.MemoryLimit <= 0 {
+		opts.MemoryLimit = 1 << 20
+	}
+	if opts.MaxSize > 0 && opts.MaxSize < opts.MemoryLimit {
+		opts.MemoryLimit = opts.MaxSize
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, opts.MemoryLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if n <= opts.MemoryLimit {
+		return &SpooledFile{mem: bytes.NewReader(buf.Bytes()), size: n}, nil
+	}
+	if opts.MaxSize > 0 && n > opts.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	f, err := os.CreateTemp(opts.Dir, "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	t := &TempFile{File: f}
+	if err := t.fill(io.MultiReader(&buf, r), opts.MaxSize); err != nil {
+		t.Cleanup()
+		return nil, err
+	}
+	cleanupOnDone(ctx, t.Cleanup)
+
+	return &SpooledFile{file: t, size: t.Size}, nil
+}
+
+// Size returns the number of bytes spooled.
+func (s *SpooledFile) Size() int64 {
+	return s.size
+}
+
+// InMemory reports whether the data stayed in memory.
+func (s *SpooledFile) InMemory() bool {
+	return s.file == nil
+}
+
+func (s *SpooledFile) Read(p []byte) (int, error) {
+	if s.file != nil {
+		return s.file.Read(p)
+	}
+
+	return s.mem.Read(p)
+}
+
+func (s *SpooledFile) ReadAt(p []byte, off int64) (int, error) {
+	if s.file != nil {
+		return s.file.ReadAt(p, off)
+	}
+
+	return s.mem.ReadAt(p, off)
+}
+
+func (s *SpooledFile) Seek(offset int64, whence int) (int64, error) {
+	if s.file != nil {
+		return s.file.Seek(offset, whence)
+	}
+
+	return s.mem.Seek(offset, whence)
+}
+
+// Close releases the data, removing any spilled file.
+func (s *SpooledFile) Close() error {
+	if s.file != nil {
+		return s.file.Cleanup()
+	}
+
+	return nil
+}
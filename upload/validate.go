@@ -0,0 +1,312 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrTooLarge            = errors.New("upload: file is too large")
+	ErrEmpty               = errors.New("upload: file is empty")
+	ErrTypeNotAllowed      = errors.New("upload: file type is not allowed")
+	ErrExtensionNotAllowed = errors.New("upload: file extension is not allowed")
+	ErrExtensionMismatch   = errors.New("upload: file extension does not match its content")
+	ErrImageTooLarge       = errors.New("upload: image dimensions are too large")
+	ErrImageUnreadable     = errors.New("upload: image dimensions cannot be read")
+	ErrUnsafeArchive       = errors.New("upload: archive contains unsafe entries")
+)
+
+type ValidateOptions struct {
+	// MaxSize is the maximum file size in bytes. Zero means unlimited.
+	MaxSize int64
+	// AllowedTypes lists permitted sniffed MIME types. Wildcards such as "image/*" are
+	// allowed. Empty allows every type.
+	AllowedTypes []string
+	// AllowedExtensions lists permitted filename extensions, e.g. ".png". Empty
+	// allows every extension.
+	AllowedExtensions []string
+	// RequireExtensionMatch rejects files whose extension maps to a different MIME
+	// type than the sniffed one, e.g. an executable named photo.jpg.
+	RequireExtensionMatch bool
+	// MaxWidth, MaxHeight and MaxPixels bound image dimensions. Zero means unlimited.
+	// When any is set, images whose dimensions cannot be read, such as HEIC and AVIF
+	// without a registered decoder, are rejected with ErrImageUnreadable.
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+	// MaxZipEntries and MaxZipUncompressed bound ZIP archives (including DOCX/XLSX)
+	// against zip bombs. Zero means unlimited. MaxZipUncompressed is enforced on the
+	// bytes actually inflated, not the sizes the entries declare. Entries are always
+	// checked for path traversal.
+	MaxZipEntries      int
+	MaxZipUncompressed int64
+}
+
+// Info describes a validated upload.
+type Info struct {
+	ContentType string
+	Extension   string
+	Size        int64
+	Width       int
+	Height      int
+}
+
+// ValidateUpload opens the multipart file and validates it with Validate.
+func ValidateUpload(fh *multipart.FileHeader, opts ValidateOptions) (*Info, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Validate(f, fh.Size, fh.Filename, opts)
+}
+
+// Validate checks a file's real type by its magic bytes against the allowlists, bounds
+// image dimensions and scans ZIP archives for path traversal and zip bombs.
+func Validate(r io.ReaderAt, size int64, filename string, opts ValidateOptions) (*Info, error) {
+	if size == 0 {
+		return nil, ErrEmpty
+	}
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	info := &Info{
+		ContentType: Sniff(head),
+		Extension:   strings.ToLower(filepath.Ext(filename)),
+		Size:        size,
+	}
+
+	if len(opts.AllowedExtensions) > 0 && !containsFold(opts.AllowedExtensions, info.Extension) {
+		return nil, ErrExtensionNotAllowed
+	}
+	if !TypeAllowed(info.ContentType, opts.AllowedTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, info.ContentType)
+	}
+	if opts.RequireExtensionMatch && !extensionMatches(info.Extension, info.ContentType) {
+		return nil, ErrExtensionMismatch
+	}
+
+	if strings.HasPrefix(info.ContentType, "image/") {
+		limited := opts.MaxWidth > 0 || opts.MaxHeight > 0 || opts.MaxPixels > 0
+		w, h, ok := imageSize(r, head, info.ContentType)
+		if !ok && limited {
+			return nil, fmt.Errorf("%w: %s", ErrImageUnreadable, info.ContentType)
+		}
+		if ok {
+			info.Width, info.Height = w, h
+			if (opts.MaxWidth > 0 && w > opts.MaxWidth) ||
+				(opts.MaxHeight > 0 && h > opts.MaxHeight) ||
+				(opts.MaxPixels > 0 && int64(w)*int64(h) > opts.MaxPixels) {
+				return nil, ErrImageTooLarge
+			}
+		}
+	}
+
+	if info.ContentType == "application/zip" {
+		if err := scanZip(r, size, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+// Sniff returns the MIME type of content from its leading bytes. It extends
+// http.DetectContentType with a few formats it does not know.
+func Sniff(head []byte) string {
+	if len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")) {
+		switch string(head[8:12]) {
+		case "heic", "heix", "mif1", "msf1":
+			return "image/heic"
+		case "avif", "avis":
+			return "image/avif"
+		}
+	}
+
+	ct := http.DetectContentType(head)
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		return mt
+	}
+
+	return ct
+}
+
+// TypeAllowed reports whether contentType matches one of allowed, which may contain
+// wildcards such as "image/*". An empty allowlist allows everything.
+func TypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == contentType || a == "*/*" {
+			return true
+		}
+		if prefix := strings.TrimSuffix(a, "*"); prefix != a && strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extensionMatches reports whether ext is registered for contentType. Office
+// documents sniff as ZIP and plain-text formats as text/plain, so both are accepted
+// for their common extensions.
+func extensionMatches(ext, contentType string) bool {
+	switch contentType {
+	case "application/zip":
+		return containsFold([]string{".zip", ".docx", ".xlsx", ".pptx", ".odt", ".ods", ".jar", ".epub"}, ext)
+	case "text/plain":
+		return containsFold([]string{".txt", ".csv", ".tsv", ".json", ".md", ".log", ".xml", ".yaml", ".yml"}, ext)
+	}
+
+	exts, _ := mime.ExtensionsByType(contentType)
+	if containsFold(exts, ext) {
+		return true
+	}
+	if byExt := mime.TypeByExtension(ext); byExt != "" {
+		mt, _, _ := mime.ParseMediaType(byExt)
+		return mt == contentType
+	}
+
+	return false
+}
+
+func imageSize(r io.ReaderAt, head []byte, contentType string) (int, int, bool) {
+	if contentType == "image/webp" {
+		return webpSize(head)
+	}
+
+	cfg, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, 1<<62))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
+
+// webpSize reads the dimensions from a WebP header without a WebP decoder.
+func webpSize(b []byte) (int, int, bool) {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return 0, 0, false
+	}
+
+	switch string(b[12:16]) {
+	case "VP8 ":
+		// Lossy: 14-bit width and height after the frame tag and start code.
+		w := int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+		return w, h, true
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, true
+	case "VP8X":
+		w := int(b[24]) | int(b[25])<<8 | int(b[26])<<16
+		h := int(b[27]) | int(b[28])<<8 | int(b[29])<<16
+		return w + 1, h + 1, true
+	}
+
+	return 0, 0, false
+}
+
+// SafeArchivePath reports whether name, taken from an archive entry, stays inside the
+// extraction directory.
+func SafeArchivePath(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return false
+	}
+	if len(name) >= 2 && name[1] == ':' {
+		return false
+	}
+
+	clean := path.Clean(name)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+func scanZip(r io.ReaderAt, size int64, opts ValidateOptions) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsafeArchive, err)
+	}
+
+	if opts.MaxZipEntries > 0 && len(zr.File) > opts.MaxZipEntries {
+		return fmt.Errorf("%w: too many entries", ErrUnsafeArchive)
+	}
+
+	for _, f := range zr.File {
+		if !SafeArchivePath(f.Name) {
+			return fmt.Errorf("%w: %q", ErrUnsafeArchive, f.Name)
+		}
+		if f.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: symlink %q", ErrUnsafeArchive, f.Name)
+		}
+	}
+
+	if opts.MaxZipUncompressed > 0 {
+		return inflatedSize(zr, opts.MaxZipUncompressed)
+	}
+
+	return nil
+}
+
+// inflatedSize decompresses every entry, counting the bytes rather than trusting the
+// declared sizes, and stops as soon as the total passes max.
+func inflatedSize(zr *zip.Reader, max int64) error {
+	remaining := max
+	for _, f := range zr.File {
+		if f.UncompressedSize64 > uint64(remaining) {
+			return fmt.Errorf("%w: uncompressed size too large", ErrUnsafeArchive)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnsafeArchive, err)
+		}
+		n, err := io.Copy(io.Discard, io.LimitReader(rc, remaining+1))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnsafeArchive, err)
+		}
+		if remaining -= n; remaining < 0 {
+			return fmt.Errorf("%w: uncompressed size too large", ErrUnsafeArchive)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidEmail       = errors.New("must be a valid email address")
+	ErrDisposableEmail    = errors.New("must not be a disposable email address")
+	ErrUndeliverableEmail = errors.New("email domain does not accept mail")
+)
+
+//go:embed disposable_domains.txt
+var disposableDomainsTxt string
+
+var (
+	disposableOnce    sync.Once
+	disposableDomains map[string]bool
+)
+
+// IsDisposableDomain reports whether domain, or a parent of it, is on the embedded
+// list of disposable email providers.
+func IsDisposableDomain(domain string) bool {
+	disposableOnce.Do(func() {
+		disposableDomains = make(map[string]bool)
+		sc := bufio.NewScanner(strings.NewReader(disposableDomainsTxt))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				disposableDomains[strings.ToLower(line)] = true
+			}
+		}
+	})
+
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+
+	return false
+}
+
+// EmailCheck configures ValidateEmailDeliverability.
+type EmailCheck struct {
+	// AllowDisposable skips the disposable-domain check.
+	AllowDisposable bool
+	// SkipMX skips the DNS lookup.
+	SkipMX bool
+	// Timeout bounds the DNS lookup. Defaults to 3 seconds.
+	Timeout time.Duration
+	// Resolver defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// ValidateEmailDeliverability checks addr's syntax against EmailRX, rejects
+// disposable domains and verifies the domain has MX records (or, failing that, an
+// A/AAAA record as RFC 5321 allows). It returns ErrInvalidEmail, ErrDisposableEmail or
+// ErrUndeliverableEmail, whose messages suit Validator.AddError. DNS failures other
+// than "no such host" are returned as-is so callers can decide whether to fail open.
+func ValidateEmailDeliverability(ctx context.Context, addr string) error {
+	return EmailCheck{}.Validate(ctx, addr)
+}
+
+func (c EmailCheck) Validate(ctx context.Context, addr string) error {
+	addr = strings.TrimSpace(addr)
+	if len(addr) > 254 || !Matches(addr, EmailRX) {
+		return ErrInvalidEmail
+	}
+
+	at := strings.LastIndexByte(addr, '@')
+	if at > 64 {
+		return ErrInvalidEmail
+	}
+	domain := strings.ToLower(addr[at+1:])
+	if !strings.Contains(domain, ".") {
+		return ErrInvalidEmail
+	}
+
+	if !c.AllowDisposable && IsDisposableDomain(domain) {
+		return ErrDisposableEmail
+	}
+	if c.SkipMX {
+		return nil
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	mxs, err := resolver.LookupMX(ctx, domain)
+	if err == nil && len(mxs) > 0 {
+		// A single "." MX is the RFC 7505 null MX: the domain accepts no mail.
+		if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+			return ErrUndeliverableEmail
+		}
+		return nil
+	}
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	hosts, err := resolver.LookupHost(ctx, domain)
+	if err != nil {
+		if isNotFound(err) {
+			return ErrUndeliverableEmail
+		}
+		return err
+	}
+	if len(hosts) == 0 {
+		return ErrUndeliverableEmail
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -1,9 +1,14 @@
 package validator
 
-import "regexp"
+import (
+	"regexp"
+	"unicode/utf8"
+)
 
 var (
 	EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+	// URLRX matches absolute http and https URLs with a host.
+	URLRX = regexp.MustCompile(`^https?://[^\s/?#@]+([/?#][^\s]*)?$`)
 )
 
 type Validator struct {
@@ -60,3 +65,13 @@ func Unique(values []string) bool {
 
 	return len(values) == len(uniqueValues)
 }
+
+// MinLen returns true if a string value contains at least n characters.
+func MinLen(value string, n int) bool {
+	return utf8.RuneCountInString(value) >= n
+}
+
+// MaxLen returns true if a string value contains no more than n characters.
+func MaxLen(value string, n int) bool {
+	return utf8.RuneCountInString(value) <= n
+}
@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrEditConflict   = errors.New("edit conflict")
+	ErrInvalidVersion = errors.New("invalid version")
+	ErrNoWhereClause  = errors.New("versioned update has no WHERE clause")
+)
+
+// ReadExpectedVersion returns the version the client expects to update, from an
+// If-Match header such as "3" (quotes and a W/ prefix are accepted) or, failing
+// that, from bodyVersion, typically a pointer field of the decoded input. ok is false
+// when the client sent neither, in which case the update can run unchecked.
+func ReadExpectedVersion(r *http.Request, bodyVersion *int64) (int64, bool, error) {
+	if h := strings.TrimSpace(r.Header.Get("If-Match")); h != "" {
+		h = strings.Trim(strings.TrimPrefix(h, "W/"), `"`)
+		v, err := strconv.ParseInt(h, 10, 64)
+		if err != nil || v < 1 {
+			return 0, false, ErrInvalidVersion
+		}
+		return v, true, nil
+	}
+
+	if bodyVersion != nil {
+		if *bodyVersion < 1 {
+			return 0, false, ErrInvalidVersion
+		}
+		return *bodyVersion, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// SetVersionETag sets the ETag a client echoes back in If-Match.
+func SetVersionETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+var (
+	// returningClause finds a RETURNING clause however it is broken across lines.
+	returningClause = regexp.MustCompile(`(?i)\sRETURNING\s`)
+	whereClause     = regexp.MustCompile(`(?i)\sWHERE\s`)
+)
+
+// VersionedUpdate adds "AND version = ?" to the WHERE clause of an UPDATE written
+// with ? placeholders, rebinds the query for dialect and returns it with version added
+// to args. The existing condition is parenthesized, so an OR in it cannot bypass the
+// check, and a trailing RETURNING clause is kept at the end. It returns
+// ErrNoWhereClause for an UPDATE without one:
+//
+//	query, args, err := helpers.VersionedUpdate(
+//		"UPDATE movies SET title = ?, version = version + 1 WHERE id = ? RETURNING version",
+//		helpers.DialectPostgres, []interface{}{m.Title, m.ID}, m.Version)
+//	if err != nil {
+//		return err
+//	}
+//	err = helpers.CheckEditConflict(db.QueryRowContext(ctx, query, args...).Scan(&m.Version))
+func VersionedUpdate(query string, dialect Dialect, args []interface{}, version int64) (string, []interface{}, error) {
+	query = strings.TrimRight(query, "; \n\t")
+	end, rest := len(query), ""
+	if loc := returningClause.FindAllStringIndex(query, -1); loc != nil {
+		end = loc[len(loc)-1][0]
+		rest = query[end:]
+	}
+
+	where := -1
+	for _, loc := range whereClause.FindAllStringIndex(query[:end], -1) {
+		if topLevel(query[:loc[0]]) {
+			where = loc[1]
+			break
+		}
+	}
+	if where < 0 {
+		return "", nil, ErrNoWhereClause
+	}
+
+	cond := strings.TrimSpace(query[where:end])
+	query = query[:where] + "(" + cond + ") AND version = ?" + rest
+	args = append(args[:len(args):len(args)], version)
+
+	return Rebind(query, dialect), args, nil
+}
+
+// topLevel reports whether the end of prefix is outside any parentheses and string
+// literal, so a WHERE there belongs to the statement rather than a subquery.
+func topLevel(prefix string) bool {
+	depth, quoted := 0, false
+	for _, c := range prefix {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		}
+	}
+
+	return depth == 0 && !quoted
+}
+
+// CheckEditConflict maps the sql.ErrNoRows of a versioned UPDATE ... RETURNING to
+// ErrEditConflict, passing other errors through.
+func CheckEditConflict(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEditConflict
+	}
+
+	return err
+}
+
+// CheckRowsAffected returns ErrEditConflict when a versioned UPDATE without RETURNING
+// changed no rows.
+func CheckRowsAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package vobject
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingUID   = errors.New("vobject: event has no UID")
+	ErrMissingStart = errors.New("vobject: event has no start time")
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Recurrence is an RRULE. Fields are left out when zero.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	// Count and Until end the recurrence; set at most one.
+	Count int
+	Until time.Time
+	// ByDay holds weekdays as MO, TU, ..., optionally with an ordinal such as 1MO or
+	// -1FR.
+	ByDay      []string
+	ByMonthDay []int
+}
+
+func (r Recurrence) value(allDay bool) string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if allDay {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Event is a VEVENT. Start and End are written in their time.Location; zones other
+// than UTC and time.Local get a VTIMEZONE so clients show the event at the right
+// local time, including across DST changes. time.Local is converted to UTC since it
+// has no portable name.
+type Event struct {
+	// UID identifies the event across updates, e.g. "order-42@example.com".
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	// End defaults to Start, or the next day for all-day events.
+	End time.Time
+	// AllDay writes Start and End as dates.
+	AllDay     bool
+	Recurrence *Recurrence
+	// ExDates are occurrences of a recurring event to skip.
+	ExDates []time.Time
+	// Organizer is an email address.
+	Organizer string
+	// Status is TENTATIVE, CONFIRMED or CANCELLED.
+	Status string
+	// Reminder adds an alarm this long before Start.
+	Reminder time.Duration
+	// Stamp is when the event was created or last changed. Defaults to now.
+	Stamp time.Time
+}
+
+type Calendar struct {
+	// ProdID identifies the product generating the calendar. Defaults to
+	// "-//go-helpers//vobject//EN".
+	ProdID string
+	// Name is shown by some clients as the calendar title.
+	Name string
+	// Method is PUBLISH by default, which suits "add to calendar" downloads.
+	Method string
+	Events []Event
+}
+
+// Encode returns the calendar as an iCalendar document.
+func (c Calendar) Encode() ([]byte, error) {
+	lw := &lineWriter{}
+	prodID := c.ProdID
+	if prodID == "" {
+		prodID = "-//go-helpers//vobject//EN"
+	}
+	method := c.Method
+	if method == "" {
+		method = "PUBLISH"
+	}
+
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", prodID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", method)
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	for _, z := range c.zones() {
+		writeTimezone(lw, z.loc, z.from, z.to)
+	}
+	for _, e := range c.Events {
+		if err := writeEvent(lw, e); err != nil {
+			return nil, err
+		}
+	}
+	lw.line("END", "VCALENDAR")
+
+	return lw.buf.Bytes(), nil
+}
+
+// WriteCalendar sends c as text/calendar. A non-empty filename, such as
+// "event.ics", makes it a download.
+func WriteCalendar(w http.ResponseWriter, filename string, c Calendar) error {
+	body, err := c.Encode()
+	if err != nil {
+		return err
+	}
+
+	return write(w, "text/calendar; charset=utf-8", filename, body)
+}
+
+// hasZone reports whether t is written with a TZID.
+func hasZone(t time.Time) bool {
+	loc := t.Location()
+	return loc != time.UTC && loc != time.Local && loc.String() != "UTC" && loc.String() != ""
+}
+
+type zoneSpan struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// zones returns the time zones used by the events, with the span of time each one
+// needs transitions for. Every time written with a TZID counts: start, end and
+// excluded dates.
+func (c Calendar) zones() []zoneSpan {
+	spans := map[string]*zoneSpan{}
+	for _, e := range c.Events {
+		if e.AllDay || e.Start.IsZero() {
+			continue
+		}
+		to := e.End
+		if e.Recurrence != nil {
+			// Open-ended rules get a year of transitions; clients repeat the last ones.
+			to = e.Start.AddDate(1, 0, 0)
+			if !e.Recurrence.Until.IsZero() && e.Recurrence.Until.After(to) {
+				to = e.Recurrence.Until
+			}
+		}
+		if to.Before(e.Start) {
+			to = e.Start
+		}
+
+		times := append([]time.Time{e.Start, e.End}, e.ExDates...)
+		for _, t := range times {
+			if t.IsZero() || !hasZone(t) {
+				continue
+			}
+			from, until := e.Start, to
+			if t.Before(from) {
+				from = t
+			}
+			if t.After(until) {
+				until = t
+			}
+
+			name := t.Location().String()
+			s, ok := spans[name]
+			if !ok {
+				spans[name] = &zoneSpan{loc: t.Location(), from: from, to: until}
+				continue
+			}
+			if from.Before(s.from) {
+				s.from = from
+			}
+			if until.After(s.to) {
+				s.to = until
+			}
+		}
+	}
+
+	out := make([]zoneSpan, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].loc.String() < out[j].loc.String() })
+
+	return out
+}
+
+// writeTimezone writes a VTIMEZONE with every offset change of loc from the start of
+// from's year to the end of to's year.
+func writeTimezone(lw *lineWriter, loc *time.Location, from, to time.Time) {
+	lw.line("BEGIN", "VTIMEZONE")
+	lw.line("TZID", loc.String())
+
+	start := time.Date(from.Year(), 1, 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.Year()+1, 1, 1, 0, 0, 0, 0, loc)
+	found := false
+	for t := start; t.Before(end); t = t.Add(24 * time.Hour) {
+		next := t.Add(24 * time.Hour)
+		if offsetOf(t) == offsetOf(next) {
+			continue
+		}
+		// Narrow the change down to the second.
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if offsetOf(mid) == offsetOf(lo) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		writeObservance(lw, lo, hi)
+		found = true
+	}
+	if !found {
+		writeObservance(lw, start, start)
+	}
+
+	lw.line("END", "VTIMEZONE")
+}
+
+func offsetOf(t time.Time) int {
+	_, off := t.Zone()
+	return off
+}
+
+// writeObservance writes the STANDARD or DAYLIGHT component for the change from the
+// offset at before to the offset at after.
+func writeObservance(lw *lineWriter, before, after time.Time) {
+	kind := "STANDARD"
+	if after.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	name, offTo := after.Zone()
+	offFrom := offsetOf(before)
+
+	lw.line("BEGIN", kind)
+	// DTSTART is the local time of the change, before it happens.
+	local := after.UTC().Add(time.Duration(offFrom) * time.Second)
+	if before.Equal(after) {
+		local = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	lw.line("DTSTART", local.Format("20060102T150405"))
+	lw.line("TZOFFSETFROM", formatOffset(offFrom))
+	lw.line("TZOFFSETTO", formatOffset(offTo))
+	lw.line("TZNAME", escapeText(name))
+	lw.line("END", kind)
+}
+
+func formatOffset(secs int) string {
+	sign := "+"
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, secs/3600, secs/60%60)
+	if secs%60 != 0 {
+		s += fmt.Sprintf("%02d", secs%60)
+	}
+
+	return s
+}
+
+// writeTime writes a DATE-TIME or DATE property.
+func writeTime(lw *lineWriter, name string, t time.Time, allDay bool) {
+	switch {
+	case allDay:
+		lw.prop(name, []string{"VALUE=DATE"}, t.Format("20060102"))
+	case hasZone(t):
+		lw.prop(name, []string{"TZID=" + t.Location().String()}, t.Format("20060102T150405"))
+	default:
+		lw.line(name, t.UTC().Format("20060102T150405Z"))
+	}
+}
+
+func writeEvent(lw *lineWriter, e Event) error {
+	if e.UID == "" {
+		return ErrMissingUID
+	}
+	if e.Start.IsZero() {
+		return ErrMissingStart
+	}
+
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	end := e.End
+	if end.IsZero() {
+		end = e.Start
+		if e.AllDay {
+			end = e.Start.AddDate(0, 0, 1)
+		}
+	}
+
+	lw.line("BEGIN", "VEVENT")
+	lw.line("UID", escapeText(e.UID))
+	lw.line("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+	writeTime(lw, "DTSTART", e.Start, e.AllDay)
+	writeTime(lw, "DTEND", end, e.AllDay)
+	if e.Recurrence != nil {
+		lw.line("RRULE", e.Recurrence.value(e.AllDay))
+	}
+	for _, ex := range e.ExDates {
+		writeTime(lw, "EXDATE", ex, e.AllDay)
+	}
+	if e.Summary != "" {
+		lw.line("SUMMARY", escapeText(e.Summary))
+	}
+	if e.Description != "" {
+		lw.line("DESCRIPTION", escapeText(e.Description))
+	}
+	if e.Location != "" {
+		lw.line("LOCATION", escapeText(e.Location))
+	}
+	if e.URL != "" {
+		lw.line("URL", e.URL)
+	}
+	if e.Organizer != "" {
+		lw.line("ORGANIZER", "mailto:"+e.Organizer)
+	}
+	if e.Status != "" {
+		lw.line("STATUS", strings.ToUpper(e.Status))
+	}
+	if e.Reminder > 0 {
+		lw.line("BEGIN", "VALARM")
+		lw.line("ACTION", "DISPLAY")
+		lw.line("DESCRIPTION", escapeText(e.Summary))
+		lw.line("TRIGGER", fmt.Sprintf("-PT%dM", int(e.Reminder.Round(time.Minute)/time.Minute)))
+		lw.line("END", "VALARM")
+	}
+	lw.line("END", "VEVENT")
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package vobject
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingName = errors.New("vobject: card has no formatted name")
+)
+
+// Name is the structured N property.
+type Name struct {
+	Family     string
+	Given      string
+	Additional string
+	Prefix     string
+	Suffix     string
+}
+
+// Typed is a value with TYPE parameters, such as an email of type "work".
+type Typed struct {
+	Types []string
+	Value string
+}
+
+type Address struct {
+	Types      []string
+	Street     string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// Card is a vCard 3.0 contact, the version read by every common address book.
+type Card struct {
+	// FormattedName is the display name and is required.
+	FormattedName string
+	Name          Name
+	Org           string
+	Title         string
+	Emails        []Typed
+	Phones        []Typed
+	Addresses     []Address
+	URL           string
+	Note          string
+	Birthday      time.Time
+	UID           string
+}
+
+// EncodeCards returns cards as one vCard document.
+func EncodeCards(cards ...Card) ([]byte, error) {
+	lw := &lineWriter{}
+	for _, c := range cards {
+		if err := writeCard(lw, c); err != nil {
+			return nil, err
+		}
+	}
+
+	return lw.buf.Bytes(), nil
+}
+
+// WriteCards sends cards as text/vcard. A non-empty filename, such as
+// "contact.vcf", makes it a download.
+func WriteCards(w http.ResponseWriter, filename string, cards ...Card) error {
+	body, err := EncodeCards(cards...)
+	if err != nil {
+		return err
+	}
+
+	return write(w, "text/vcard; charset=utf-8", filename, body)
+}
+
+// structured escapes and joins the components of a structured value.
+func structured(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = escapeText(p)
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func typeParams(types []string) []string {
+	if len(types) == 0 {
+		return nil
+	}
+
+	return []string{"TYPE=" + strings.ToUpper(strings.Join(types, ","))}
+}
+
+func writeCard(lw *lineWriter, c Card) error {
+	if c.FormattedName == "" {
+		return ErrMissingName
+	}
+
+	lw.line("BEGIN", "VCARD")
+	lw.line("VERSION", "3.0")
+	lw.line("FN", escapeText(c.FormattedName))
+	n := c.Name
+	lw.line("N", structured(n.Family, n.Given, n.Additional, n.Prefix, n.Suffix))
+	if c.Org != "" {
+		lw.line("ORG", escapeText(c.Org))
+	}
+	if c.Title != "" {
+		lw.line("TITLE", escapeText(c.Title))
+	}
+	for _, e := range c.Emails {
+		lw.prop("EMAIL", append([]string{"TYPE=INTERNET"}, typeParams(e.Types)...), escapeText(e.Value))
+	}
+	for _, p := range c.Phones {
+		lw.prop("TEL", typeParams(p.Types), escapeText(p.Value))
+	}
+	for _, a := range c.Addresses {
+		// The post office box and extended address components are left empty.
+		lw.prop("ADR", typeParams(a.Types), structured("", "", a.Street, a.City, a.Region, a.PostalCode, a.Country))
+	}
+	if c.URL != "" {
+		lw.line("URL", c.URL)
+	}
+	if c.Note != "" {
+		lw.line("NOTE", escapeText(c.Note))
+	}
+	if !c.Birthday.IsZero() {
+		lw.line("BDAY", c.Birthday.Format("2006-01-02"))
+	}
+	if c.UID != "" {
+		lw.line("UID", escapeText(c.UID))
+	}
+	lw.line("END", "VCARD")
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package vobject writes iCalendar (RFC 5545) and vCard (RFC 2426) payloads, with
+// the escaping and 75-octet line folding both formats require.
+package vobject
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineWriter writes content lines ending in CRLF and folded at 75 octets.
+type lineWriter struct {
+	buf bytes.Buffer
+}
+
+func (lw *lineWriter) line(name, value string) {
+	lw.fold(name + ":" + value)
+}
+
+// prop writes a property with parameters, e.g. DTSTART;TZID=Europe/Paris:2024...
+func (lw *lineWriter) prop(name string, params []string, value string) {
+	if len(params) > 0 {
+		name += ";" + strings.Join(params, ";")
+	}
+	lw.line(name, value)
+}
+
+func (lw *lineWriter) fold(s string) {
+	const limit = 75
+	n := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		// Continuation lines start with a space, which counts towards the limit.
+		if n+size > limit {
+			lw.buf.WriteString("\r\n ")
+			n = 1
+		}
+		lw.buf.WriteString(s[:size])
+		n += size
+		s = s[size:]
+	}
+	lw.buf.WriteString("\r\n")
+}
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		// A lone CR would end the content line in lenient parsers.
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// write sends body as an attachment named filename, or inline when filename is empty.
+func write(w http.ResponseWriter, contentType, filename string, body []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	if filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(body)
+
+	return err
+}
@@ -0,0 +1,410 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	helpers "github.com/hasahmad/go-helpers"
+)
+
+var (
+	ErrClosed          = errors.New("ws: connection closed")
+	ErrMessageTooLarge = errors.New("ws: message exceeds read limit")
+)
+
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close codes from RFC 6455 section 7.4.1.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseTooLarge        = 1009
+	CloseInternalError   = 1011
+)
+
+// CloseError is returned by reads once the peer has closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("ws: closed with code %d %s", e.Code, e.Reason)
+}
+
+// Conn is a server-side WebSocket connection. One goroutine may read while others
+// write; writes are serialized internally.
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	opts     UpgradeOptions
+	protocol string
+
+	writeMu sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newConn(netConn net.Conn, br *bufio.Reader, protocol string, opts UpgradeOptions) *Conn {
+	c := &Conn{conn: netConn, br: br, opts: opts, protocol: protocol, done: make(chan struct{})}
+	c.extendReadDeadline()
+	if opts.PingInterval > 0 {
+		go c.keepalive()
+	}
+
+	return c
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *Conn) Subprotocol() string {
+	return c.protocol
+}
+
+// Done is closed once the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Conn) keepalive() {
+	t := time.NewTicker(c.opts.PingInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+			if err := c.writeFrame(context.Background(), opPing, nil); err != nil {
+				c.closeConn()
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) extendReadDeadline() {
+	if c.opts.PongWait > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+	}
+}
+
+// watch makes blocking I/O return once ctx is done by moving the deadline into the
+// past. The returned function stops watching and waits for the watcher to exit, so
+// no deadline is moved after it returns.
+func watch(ctx context.Context, setDeadline func(time.Time) error) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			setDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// ReadMessage returns the next data message, answering pings and handling close
+// frames along the way. It returns ctx.Err() if ctx is done first, after which the
+// connection should be closed.
+func (c *Conn) ReadMessage(ctx context.Context) (MessageType, []byte, error) {
+	stop := watch(ctx, c.conn.SetReadDeadline)
+	defer stop()
+
+	typ, msg, err := c.readMessage()
+	if err != nil && ctx.Err() != nil {
+		return 0, nil, ctx.Err()
+	}
+
+	return typ, msg, err
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ     MessageType
+		msg     []byte
+		started bool
+	)
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.extendReadDeadline()
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(context.Background(), opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+			}
+			// 1005 only reports a missing code and must not be sent (RFC 6455 7.4.1).
+			reply := ce.Code
+			if reply == CloseNoStatus {
+				reply = CloseNormal
+			}
+			c.Close(reply, "")
+			return 0, nil, ce
+		case opText, opBinary:
+			if started {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			typ, started = MessageType(op), true
+		case opContinuation:
+			if !started {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(msg)+len(payload)) > c.opts.ReadLimit {
+			c.fail(CloseTooLarge, "message too large")
+			return 0, nil, ErrMessageTooLarge
+		}
+		msg = append(msg, payload...)
+
+		if fin {
+			if typ == TextMessage && !utf8.Valid(msg) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return typ, msg, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := head[0]&0x80 != 0
+	op := head[0] & 0x0F
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	length := int64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(b[:]))
+	}
+
+	if op >= opClose && (!fin || length > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length < 0 || length > c.opts.ReadLimit {
+		c.fail(CloseTooLarge, "message too large")
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, op, payload, nil
+}
+
+// fail closes the connection with code after a protocol violation.
+func (c *Conn) fail(code int, reason string) error {
+	c.Close(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// ReadJSON reads the next message and decodes it into v, rejecting unknown fields
+// like ReadJSON in the root package.
+func (c *Conn) ReadJSON(ctx context.Context, v interface{}) error {
+	_, msg, err := c.ReadMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("message contains invalid JSON: %w", err)
+	}
+
+	return nil
+}
+
+// WriteMessage sends a single data frame.
+func (c *Conn) WriteMessage(ctx context.Context, typ MessageType, data []byte) error {
+	return c.writeFrame(ctx, byte(typ), data)
+}
+
+// WriteJSON sends v as a text message.
+func (c *Conn) WriteJSON(ctx context.Context, v interface{}) error {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(ctx, TextMessage, js)
+}
+
+// WriteEnvelope sends data wrapped in the package's envelope format.
+func (c *Conn) WriteEnvelope(ctx context.Context, data helpers.Envelope) error {
+	js, err := data.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(ctx, TextMessage, js)
+}
+
+// WriteError sends {"error": message}, mirroring the HTTP error responses.
+func (c *Conn) WriteError(ctx context.Context, message interface{}) error {
+	return c.WriteEnvelope(ctx, helpers.Envelope{"error": message})
+}
+
+func (c *Conn) writeFrame(ctx context.Context, op byte, payload []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline := time.Now().Add(c.opts.WriteTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetWriteDeadline(deadline)
+	stop := watch(ctx, c.conn.SetWriteDeadline)
+	defer stop()
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(n))
+		frame = append(append(frame, 127), b[:]...)
+	}
+	frame = append(frame, payload...)
+
+	if _, err := c.conn.Write(frame); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Close sends a close frame with code and reason, then closes the connection. It is
+// safe to call more than once.
+func (c *Conn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := c.writeFrame(ctx, opClose, payload)
+	c.closeConn()
+
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// CloseOnDone closes the connection with CloseGoingAway when ctx is done, e.g. on
+// server shutdown.
+func (c *Conn) CloseOnDone(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close(CloseGoingAway, "")
+		case <-c.done:
+		}
+	}()
+}
+
+func (c *Conn) closeConn() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,182 @@
+// Package ws is a small server-side WebSocket (RFC 6455) implementation with JSON
+// helpers, origin checks, keepalive pings and context-aware reads and writes.
+package ws
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadHandshake     = errors.New("ws: not a websocket handshake")
+	ErrOriginNotAllowed = errors.New("ws: origin not allowed")
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type UpgradeOptions struct {
+	// AllowedOrigins lists origins (scheme://host[:port]) allowed besides the request's
+	// own host. "*" allows any origin.
+	AllowedOrigins []string
+	// CheckOrigin replaces the AllowedOrigins check when set.
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols are offered in order of preference.
+	Subprotocols []string
+	// ReadLimit is the maximum message size. Defaults to 1MB.
+	ReadLimit int64
+	// PingInterval is how often a ping is sent. Defaults to 30s; negative disables
+	// keepalive.
+	PingInterval time.Duration
+	// PongWait is how long the peer may stay silent before the connection is
+	// considered dead. Defaults to twice PingInterval.
+	PongWait time.Duration
+	// WriteTimeout bounds each write. Defaults to 10s.
+	WriteTimeout time.Duration
+}
+
+// Upgrade performs the handshake and takes over the connection. On failure it has
+// already answered the request with a 4xx status.
+func Upgrade(w http.ResponseWriter, r *http.Request, opts UpgradeOptions) (*Conn, error) {
+	if opts.ReadLimit <= 0 {
+		opts.ReadLimit = 1 << 20
+	}
+	if opts.PingInterval == 0 {
+		opts.PingInterval = 30 * time.Second
+	}
+	if opts.PongWait <= 0 && opts.PingInterval > 0 {
+		opts.PongWait = 2 * opts.PingInterval
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = func(r *http.Request) bool { return originAllowed(r, opts.AllowedOrigins) }
+	}
+	if !checkOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return nil, ErrOriginNotAllowed
+	}
+
+	hj, ok := hijacker(w)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil, errors.New("ws: response writer does not support hijacking")
+	}
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n"
+	protocol := selectSubprotocol(r, opts.Subprotocols)
+	if protocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	resp += "\r\n"
+
+	netConn.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
+	if _, err := netConn.Write([]byte(resp)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetWriteDeadline(time.Time{})
+
+	br := brw.Reader
+	if br == nil {
+		br = bufio.NewReader(netConn)
+	}
+
+	return newConn(netConn, br, protocol, opts), nil
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// originAllowed accepts requests without an Origin header (non-browser clients),
+// same-host origins and those listed.
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimRight(a, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	var requested []string
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			requested = append(requested, strings.TrimSpace(p))
+		}
+	}
+
+	for _, s := range supported {
+		for _, p := range requested {
+			if p == s {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
+
+// hijacker finds the http.Hijacker under middleware wrappers that expose the
+// underlying writer through Unwrap, as http.ResponseController does.
+func hijacker(w http.ResponseWriter) (http.Hijacker, bool) {
+	for {
+		if hj, ok := w.(http.Hijacker); ok {
+			return hj, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,134 @@
+package helpers
+
+import (
+	"archive/zip"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZipEntry is a file to add to a streamed archive. Open is called only when the entry
+// is written, so at most one file is open at a time.
+type ZipEntry struct {
+	Name     string
+	Modified time.Time
+	Open     func() (io.ReadCloser, error)
+}
+
+// ZipSource yields archive entries. Next returns io.EOF when there are no more.
+type ZipSource interface {
+	Next() (*ZipEntry, error)
+}
+
+// ZipSourceFunc adapts a function to ZipSource.
+type ZipSourceFunc func() (*ZipEntry, error)
+
+func (f ZipSourceFunc) Next() (*ZipEntry, error) {
+	return f()
+}
+
+// ZipEntries returns a ZipSource over a fixed list of entries.
+func ZipEntries(entries ...ZipEntry) ZipSource {
+	i := 0
+	return ZipSourceFunc(func() (*ZipEntry, error) {
+		if i >= len(entries) {
+			return nil, io.EOF
+		}
+		i++
+		return &entries[i-1], nil
+	})
+}
+
+// storedExtensions are already compressed, so they are stored rather than deflated.
+var storedExtensions = []string{
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar",
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".mp3", ".mp4", ".mov", ".webm",
+	".docx", ".xlsx", ".pptx",
+}
+
+// StreamZip writes the entries as a zip archive directly to the response, without
+// buffering it in memory or on disk. filename is used for Content-Disposition.
+// Entry names are cleaned of path traversal and duplicates get a " (n)" suffix.
+//
+// Once the first byte is written the status is committed, so an error part way
+// through leaves the client with a truncated archive; callers should log it.
+func StreamZip(w http.ResponseWriter, filename string, files ZipSource) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	used := map[string]int{}
+
+	for {
+		entry, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := writeZipEntry(zw, entry, uniqueZipName(used, entry.Name)); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeZipEntry(zw *zip.Writer, entry *ZipEntry, name string) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: entry.Modified}
+	if header.Modified.IsZero() {
+		header.Modified = time.Now()
+	}
+	if Contains(storedExtensions, strings.ToLower(path.Ext(name))) {
+		header.Method = zip.Store
+	}
+
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+func uniqueZipName(used map[string]int, name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = strings.TrimLeft(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "file"
+	}
+
+	n := used[name]
+	used[name] = n + 1
+	if n == 0 {
+		return name
+	}
+
+	ext := path.Ext(name)
+	for {
+		candidate := strings.TrimSuffix(name, ext) + " (" + strconv.Itoa(n) + ")" + ext
+		if used[candidate] == 0 {
+			used[candidate] = 1
+			return candidate
+		}
+		n++
+	}
+}